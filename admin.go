@@ -0,0 +1,263 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// fileCoverage is the review state of a file in the PR.
+type fileCoverage struct {
+	File            string   `json:"file"`
+	Approvers       []string `json:"approvers"`
+	Reviewers       []string `json:"reviewers"`
+	AgreedApprovers []string `json:"agreed_approvers"`
+	AgreedReviewers []string `json:"agreed_reviewers"`
+}
+
+type adminReviewState struct {
+	Org    string `json:"org"`
+	Repo   string `json:"repo"`
+	Number int32  `json:"number"`
+
+	State  json.RawMessage `json:"state"`
+	Files  []fileCoverage  `json:"files"`
+	Labels []string        `json:"labels"`
+
+	// Suggested is the people who would be suggested by the Review Guide.
+	// They are computed without writing any comment.
+	Suggested struct {
+		Reviewers []string `json:"reviewers,omitempty"`
+		Approvers []string `json:"approvers,omitempty"`
+	} `json:"suggested"`
+
+	// Expertise is computed from the cached commit history only.
+	Expertise  []expertiseExplanation `json:"expertise,omitempty"`
+	Config     effectiveConfig        `json:"config"`
+	ConfigHash string                 `json:"config_hash"`
+}
+
+// adminServer serves the api to query and manage the review state of PR.
+// All the requests must be authenticated by the bearer token.
+type adminServer struct {
+	bot *robot
+}
+
+func newAdminHandler(bot *robot, token func() []byte) http.Handler {
+	s := &adminServer{bot: bot}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/review-state", s.getReviewState)
+	mux.HandleFunc("/api/v1/recompute", s.recompute)
+	mux.HandleFunc("/api/v1/clear-comments", s.clearComments)
+
+	return authenticate(mux, token)
+}
+
+// authenticate requires the requests to have the bearer token.
+func authenticate(h http.Handler, token func() []byte) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		v := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		t := token()
+
+		if len(t) == 0 || subtle.ConstantTimeCompare([]byte(v), t) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+// parsePR parses the PR from the query and finds its config.
+func (s *adminServer) parsePR(w http.ResponseWriter, r *http.Request, method string) (
+	pr prInfoOnAPI, cfg *botConfig, ok bool,
+) {
+	if r.Method != method {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	org, repo := q.Get("org"), q.Get("repo")
+	number, err := strconv.Atoi(q.Get("number"))
+	if org == "" || repo == "" || err != nil {
+		http.Error(w, "invalid org, repo or number", http.StatusBadRequest)
+		return
+	}
+
+	if cfg = s.bot.currentConfig().configFor(org, repo); cfg == nil {
+		http.Error(w, fmt.Sprintf("no config for %s/%s", org, repo), http.StatusNotFound)
+		return
+	}
+
+	if pr, err = s.bot.getPRInfo(org, repo, int32(number)); err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	ok = true
+
+	return
+}
+
+func (s *adminServer) log(r *http.Request, pr prInfoOnAPI) *logrus.Entry {
+	return logrus.WithFields(logrus.Fields{
+		"component": "admin",
+		"path":      r.URL.Path,
+		"pr":        prKey(pr),
+	})
+}
+
+func (s *adminServer) getReviewState(w http.ResponseWriter, r *http.Request) {
+	pr, cfg, ok := s.parsePR(w, r, http.MethodGet)
+	if !ok {
+		return
+	}
+
+	bot := s.bot
+
+	ctx, err := bot.genReviewContext(pr, pr.getAssignees(), cfg)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	rs, rr := ctx.info.doStats(ctx.stats, bot.botName)
+
+	org, repo := pr.getOrgAndRepo()
+	v := adminReviewState{
+		Org:    org,
+		Repo:   repo,
+		Number: pr.getNumber(),
+		State:  newAuditState(&rs, &rr),
+		Files:  genFileCoverage(ctx.pr, &rs),
+		Labels: pr.labels.List(),
+		Config: cfg.effective(),
+
+		ConfigHash: configHash(cfg),
+	}
+
+	log := s.log(r, pr)
+
+	ranker := bot.newExpertiseRanker(cfg)
+	if ranker != nil {
+		ranker.cachedOnly = true
+		v.Expertise = ranker.explain(org, repo, pr.getTargetBranch(), ctx.pr.files, log)
+	}
+
+	if !rr.isRejected && !rr.isLBTM {
+		unavailable := bot.unavailablePeople(cfg)
+		owner := newAvailableOwner(ctx.owner, unavailable)
+
+		if !rr.isLGTM {
+			v.Suggested.Reviewers, err = suggestReviewers(
+				bot.client, owner, pr, cfg.Review.TotalNumberOfReviewers, ranker, log,
+			)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadGateway)
+				return
+			}
+		}
+
+		if !rr.isApproved {
+			v.Suggested.Approvers = suggestingApprover{
+				pr:          ctx.pr,
+				cfg:         cfg.Review,
+				owner:       owner,
+				unavailable: unavailable,
+			}.suggestApprover(rs.agreedApprovers, ctx.pr.assignees, log)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func genFileCoverage(pr *pullRequest, rs *reviewSummary) []fileCoverage {
+	agreedApprovers := sets.NewString(rs.agreedApprovers...)
+	agreedReviewers := sets.NewString(rs.agreedReviewers...)
+
+	r := make([]fileCoverage, 0, len(pr.files))
+	for _, f := range pr.files {
+		approvers := pr.approversOfFile(f)
+		reviewers := pr.fileReviewerMap[f]
+
+		r = append(r, fileCoverage{
+			File:            f,
+			Approvers:       approvers.List(),
+			Reviewers:       reviewers.List(),
+			AgreedApprovers: approvers.Intersection(agreedApprovers).List(),
+			AgreedReviewers: reviewers.Union(approvers).Intersection(agreedReviewers).List(),
+		})
+	}
+
+	return r
+}
+
+// recompute recomputes the review state as handling the review comment.
+func (s *adminServer) recompute(w http.ResponseWriter, r *http.Request) {
+	pr, cfg, ok := s.parsePR(w, r, http.MethodPost)
+	if !ok {
+		return
+	}
+
+	if !pr.isOpen() {
+		http.Error(w, "the pull request is not open", http.StatusConflict)
+		return
+	}
+
+	rs, rr, err := s.bot.reviewPR(pr, pr.getAssignees(), cfg, s.log(r, pr))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(newAuditState(&rs, &rr))
+}
+
+// clearComments deletes all the comments written by the robot.
+func (s *adminServer) clearComments(w http.ResponseWriter, r *http.Request) {
+	pr, _, ok := s.parsePR(w, r, http.MethodPost)
+	if !ok {
+		return
+	}
+
+	org, repo := pr.getOrgAndRepo()
+	comments, err := s.bot.client.ListPRComments(org, repo, pr.getNumber())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	n := 0
+	mr := multiError()
+	for i := range comments {
+		c := &comments[i]
+		if c.User == nil || c.User.Login != s.bot.botName {
+			continue
+		}
+
+		if err := s.bot.client.DeletePRComment(org, repo, c.Id); err != nil {
+			mr.AddError(err)
+		} else {
+			n++
+		}
+	}
+
+	if err := mr.Err(); err != nil {
+		s.log(r, pr).WithError(err).Error("clear comments")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]int{"deleted": n})
+}
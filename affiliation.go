@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// affiliations maps the login to the organization, such as the company,
+// which the person belongs to.
+type affiliations map[string]string
+
+// loadAffiliations loads the file which lists the members of each organization:
+//
+//	company-a:
+//	- alice
+//	company-b:
+//	- bob
+func loadAffiliations(path string) (affiliations, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	v := map[string][]string{}
+	if err := yaml.Unmarshal(b, &v); err != nil {
+		return nil, fmt.Errorf("parse %s, err: %s", path, err.Error())
+	}
+
+	r := affiliations{}
+	for org, logins := range v {
+		for _, login := range logins {
+			login = normalizeLogin(login)
+			if o, ok := r[login]; ok && o != org {
+				return nil, fmt.Errorf("%s belongs to both %s and %s", login, o, org)
+			}
+
+			r[login] = org
+		}
+	}
+
+	return r, nil
+}
+
+// check makes sure that the affiliations are loaded if any repo requires
+// the approvers from different organizations, otherwise no PR of that repo
+// could be approved.
+func (a affiliations) check(cfg *configuration) error {
+	if a != nil || cfg == nil {
+		return nil
+	}
+
+	for i := range cfg.items {
+		if item := &cfg.items[i]; item.Review.MinApproverAffiliations > 1 {
+			return fmt.Errorf(
+				"min_approver_affiliations of %s requires the affiliation-file",
+				strings.Join(item.Repos, ","),
+			)
+		}
+	}
+
+	return nil
+}
+
+// unmet returns the shortfall when the approvers come from less than
+// min organizations. The approvers without affiliation are not counted.
+func (a affiliations) unmet(approvers []string, min int) string {
+	if min <= 1 {
+		return ""
+	}
+
+	orgs := sets.NewString()
+	for _, item := range approvers {
+		if o, ok := a[item]; ok {
+			orgs.Insert(o)
+		}
+	}
+
+	if n := orgs.Len(); n < min {
+		s := ""
+		if n > 0 {
+			s = fmt.Sprintf(" Now they are from: %s.", strings.Join(orgs.List(), notificationReviewersSpliter))
+		}
+
+		return fmt.Sprintf(
+			"It requires the approvers from at least **%d** different organizations, and **%d** more are needed.%s",
+			min, min-n, s,
+		)
+	}
+
+	return ""
+}
+
+// weightShortfall explains why the approvers are not enough when they are
+// weighted. It returns empty string if the weights are not configured.
+func (r reviewConfig) weightShortfall(approvers []string) string {
+	if len(r.ApproverWeights) == 0 {
+		return ""
+	}
+
+	w := r.approvalWeight(approvers)
+	if w >= r.TotalNumberOfApprovers {
+		return ""
+	}
+
+	return fmt.Sprintf(
+		"The approvers are weighted, it requires the total weight of **%d**, and **%d** more is needed.",
+		r.TotalNumberOfApprovers, r.TotalNumberOfApprovers-w,
+	)
+}
+
+// approvalWeight returns the sum of weights of the approvers.
+func (r reviewConfig) approvalWeight(approvers []string) int {
+	n := 0
+	for _, item := range approvers {
+		if w, ok := r.ApproverWeights[item]; ok {
+			n += w
+		} else {
+			n++
+		}
+	}
+	return n
+}
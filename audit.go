@@ -0,0 +1,145 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/opensourceways/robot-gitee-review-trigger/audit"
+)
+
+type auditState struct {
+	AgreedApprovers    []string `json:"agreed_approvers,omitempty"`
+	AgreedReviewers    []string `json:"agreed_reviewers,omitempty"`
+	DisagreedApprovers []string `json:"disagreed_approvers,omitempty"`
+	DisagreedReviewers []string `json:"disagreed_reviewers,omitempty"`
+
+	Reasons map[string]string `json:"reasons,omitempty"`
+
+	IsRejected  bool   `json:"is_rejected"`
+	IsApproved  bool   `json:"is_approved"`
+	IsLGTM      bool   `json:"is_lgtm"`
+	IsLBTM      bool   `json:"is_lbtm"`
+	NeedLGTMNum int    `json:"need_lgtm_num,omitempty"`
+	UnmetRule   string `json:"unmet_rule,omitempty"`
+
+	OverruledRejecters []string   `json:"overruled_rejecters,omitempty"`
+	WaitUntil          *time.Time `json:"wait_until,omitempty"`
+
+	IgnoredVoters map[string]string `json:"ignored_voters,omitempty"`
+}
+
+func newAuditState(rs *reviewSummary, rr *reviewResult) json.RawMessage {
+	s := auditState{
+		AgreedApprovers:    rs.agreedApprovers,
+		AgreedReviewers:    rs.agreedReviewers,
+		DisagreedApprovers: rs.disagreedApprovers,
+		DisagreedReviewers: rs.disagreedReviewers,
+		IsRejected:         rr.isRejected,
+		IsApproved:         rr.isApproved,
+		IsLGTM:             rr.isLGTM,
+		IsLBTM:             rr.isLBTM,
+		NeedLGTMNum:        rr.needLGTMNum,
+		UnmetRule:          rr.unmetRule,
+		OverruledRejecters: rr.overruledRejecters,
+		Reasons:            rs.reasons,
+		IgnoredVoters:      rs.ignoredVoters,
+	}
+
+	if !rr.waitUntil.IsZero() {
+		t := rr.waitUntil
+		s.WaitUntil = &t
+	}
+
+	b, _ := json.Marshal(s)
+	return b
+}
+
+// restartedReviewState is the state of review which starts over,
+// such as after a push, when no one has reviewed.
+func restartedReviewState() json.RawMessage {
+	return newAuditState(&reviewSummary{}, &reviewResult{})
+}
+
+// auditRecorder records the events of a PR to the audit store.
+// All its methods can be called on nil which means audit is disabled.
+type auditRecorder struct {
+	store audit.Store
+	pr    iPRInfo
+	actor string
+	cfg   audit.ConfigSnapshot
+	log   *logrus.Entry
+}
+
+func (bot *robot) newAuditRecorder(pr iPRInfo, actor string, cfg *botConfig, log *logrus.Entry) *auditRecorder {
+	if bot.audit == nil {
+		return nil
+	}
+
+	return &auditRecorder{
+		store: bot.audit,
+		pr:    pr,
+		actor: actor,
+		cfg:   configSnapshot(cfg),
+		log:   log,
+	}
+}
+
+// configSnapshot returns the effective config and its sha256.
+func configSnapshot(cfg *botConfig) audit.ConfigSnapshot {
+	b, err := json.Marshal(cfg.effective())
+	if err != nil {
+		return audit.ConfigSnapshot{}
+	}
+
+	return audit.ConfigSnapshot{Hash: fmt.Sprintf("%x", sha256.Sum256(b)), Config: b}
+}
+
+func configHash(cfg *botConfig) string {
+	return configSnapshot(cfg).Hash
+}
+
+func (r *auditRecorder) record(kind, detail string, state json.RawMessage) {
+	if r == nil {
+		return
+	}
+
+	// The snapshot is saved before the record, so that the config hash of
+	// any record can be resolved.
+	if r.cfg.Hash != "" {
+		if err := r.store.SaveConfig(r.cfg); err != nil {
+			r.log.WithError(err).Error("save the snapshot of config")
+		}
+	}
+
+	org, repo := r.pr.getOrgAndRepo()
+
+	err := r.store.Append(audit.Record{
+		Time:       time.Now(),
+		Org:        org,
+		Repo:       repo,
+		Number:     r.pr.getNumber(),
+		Kind:       kind,
+		Actor:      r.actor,
+		Detail:     detail,
+		State:      state,
+		ConfigHash: r.cfg.Hash,
+	})
+	if err != nil {
+		r.log.WithError(err).Errorf("record the audit of %s", kind)
+	}
+}
+
+func (r *auditRecorder) recordLabels(added, removed []string, state json.RawMessage) {
+	if len(added) > 0 {
+		r.record(audit.KindLabelAdded, strings.Join(added, ","), state)
+	}
+
+	if len(removed) > 0 {
+		r.record(audit.KindLabelRemoved, strings.Join(removed, ","), state)
+	}
+}
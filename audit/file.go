@@ -0,0 +1,190 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// fileStore saves each record as a line of json in a local file. The
+// snapshots of config are saved in the same way in another file whose
+// path has the suffix of `.config`.
+type fileStore struct {
+	lock sync.Mutex
+	path string
+	f    *os.File
+
+	configs *os.File
+	// saved is the hashes of config snapshots saved in configs.
+	saved map[string]bool
+}
+
+func newFileStore(path string) (*fileStore, error) {
+	s := &fileStore{path: path, saved: map[string]bool{}}
+
+	err := scanLines(configPath(path), func(b []byte) error {
+		item := ConfigSnapshot{}
+		if err := json.Unmarshal(b, &item); err != nil {
+			return err
+		}
+
+		s.saved[item.Hash] = true
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if s.configs, err = openForAppend(configPath(path)); err != nil {
+		return nil, err
+	}
+
+	if s.f, err = openForAppend(path); err != nil {
+		s.configs.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func configPath(path string) string {
+	return path + ".config"
+}
+
+func openForAppend(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+}
+
+func (s *fileStore) Append(r Record) error {
+	if s.f == nil {
+		return fmt.Errorf("the audit file is read-only")
+	}
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	_, err = s.f.Write(append(b, '\n'))
+
+	return err
+}
+
+func (s *fileStore) Query(org, repo string, number int32) ([]Record, error) {
+	return s.scan(func(item *Record) bool {
+		return item.Org == org && item.Repo == repo && item.Number == number
+	})
+}
+
+func (s *fileStore) List(f Filter) ([]Record, error) {
+	return s.scan(f.match)
+}
+
+func (s *fileStore) scan(match func(*Record) bool) ([]Record, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var r []Record
+
+	err := scanLines(s.path, func(b []byte) error {
+		item := Record{}
+		if err := json.Unmarshal(b, &item); err != nil {
+			return err
+		}
+
+		if match(&item) {
+			r = append(r, item)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (s *fileStore) SaveConfig(c ConfigSnapshot) error {
+	if s.configs == nil {
+		return fmt.Errorf("the audit file is read-only")
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.saved[c.Hash] {
+		return nil
+	}
+
+	b, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	if _, err = s.configs.Write(append(b, '\n')); err == nil {
+		s.saved[c.Hash] = true
+	}
+
+	return err
+}
+
+func (s *fileStore) Config(hash string) (json.RawMessage, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var r json.RawMessage
+
+	err := scanLines(configPath(s.path), func(b []byte) error {
+		item := ConfigSnapshot{}
+		if err := json.Unmarshal(b, &item); err != nil {
+			return err
+		}
+
+		if item.Hash == hash {
+			r = item.Config
+		}
+
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (s *fileStore) Close() error {
+	if s.f == nil {
+		return nil
+	}
+
+	s.configs.Close()
+
+	return s.f.Close()
+}
+
+// scanLines calls f with each line of the file.
+func scanLines(path string, f func([]byte) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		if err := f(scanner.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,138 @@
+package audit
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "audit.log")
+
+	s, err := NewStore(StoreFile, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records := []Record{
+		{Org: "o", Repo: "r", Number: 1, Kind: KindCommand, Actor: "a", Detail: "LGTM"},
+		{Org: "o", Repo: "r", Number: 2, Kind: KindLabelAdded, Detail: "lgtm"},
+		{Org: "o", Repo: "r", Number: 1, Kind: KindReviewGuide, State: []byte(`{"is_lgtm":true}`)},
+	}
+	for i := range records {
+		records[i].Time = time.Now()
+		if err := s.Append(records[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.Close()
+
+	// reopen it to make sure the records are persisted
+	s, err = NewStore(StoreFile, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	v, err := s.Query("o", "r", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(v) != 2 {
+		t.Fatalf("expect 2 records, but got %d", len(v))
+	}
+
+	if v[0].Kind != KindCommand || v[1].Kind != KindReviewGuide {
+		t.Errorf("unexpected order of records: %s, %s", v[0].Kind, v[1].Kind)
+	}
+
+	if string(v[1].State) != `{"is_lgtm":true}` {
+		t.Errorf("unexpected state: %s", v[1].State)
+	}
+}
+
+func TestReadOnlyFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "audit.log")
+
+	s, err := NewReadOnlyStore(StoreFile, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if err := s.Append(Record{Org: "o"}); err == nil {
+		t.Error("expect an error when appending to the read-only store")
+	}
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expect the file not to be created, but got %v", err)
+	}
+}
+
+func TestFileStoreConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "audit.log")
+	c := ConfigSnapshot{Hash: "h1", Config: []byte(`{"ci":{"no_ci":true}}`)}
+
+	for i := 0; i < 2; i++ {
+		// reopen it to make sure the saved snapshot is not saved again
+		s, err := NewStore(StoreFile, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := s.SaveConfig(c); err != nil {
+			t.Fatal(err)
+		}
+		s.Close()
+	}
+
+	s, err := NewReadOnlyStore(StoreFile, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	v, err := s.Config("h1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(v) != string(c.Config) {
+		t.Errorf("unexpected config: %s", v)
+	}
+
+	if v, err := s.Config("h2"); err != nil || v != nil {
+		t.Errorf("expect nil for unknown hash, but got %s, %v", v, err)
+	}
+
+	b, err := ioutil.ReadFile(configPath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := strings.Count(string(b), "\n"); n != 1 {
+		t.Errorf("expect 1 snapshot, but got %d", n)
+	}
+}
@@ -0,0 +1,58 @@
+package audit
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// NewHandler returns the handler which responds the audit trail of
+// a pull-request specified by the query of org, repo and number, or
+// the snapshot of config specified by the query of config_hash.
+func NewHandler(s Store) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		q := r.URL.Query()
+
+		if h := q.Get("config_hash"); h != "" {
+			v, err := s.Config(h)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			if v == nil {
+				http.Error(w, "config not found", http.StatusNotFound)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(v)
+			return
+		}
+
+		org, repo := q.Get("org"), q.Get("repo")
+		number, err := strconv.Atoi(q.Get("number"))
+		if org == "" || repo == "" || err != nil {
+			http.Error(w, "invalid org, repo or number", http.StatusBadRequest)
+			return
+		}
+
+		v, err := s.Query(org, repo, int32(number))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if v == nil {
+			v = []Record{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(v)
+	})
+}
@@ -0,0 +1,188 @@
+package audit
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+const (
+	sqlCreateTable = `CREATE TABLE IF NOT EXISTS review_audit (
+	id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+	created_at DATETIME(6) NOT NULL,
+	org VARCHAR(255) NOT NULL,
+	repo VARCHAR(255) NOT NULL,
+	number INT NOT NULL,
+	kind VARCHAR(64) NOT NULL,
+	actor VARCHAR(255) NOT NULL,
+	detail MEDIUMTEXT NOT NULL,
+	state MEDIUMTEXT NOT NULL,
+	config_hash VARCHAR(64) NOT NULL,
+	INDEX idx_pr (org, repo, number)
+)`
+
+	sqlCreateConfigTable = `CREATE TABLE IF NOT EXISTS review_audit_config (
+	hash VARCHAR(64) NOT NULL PRIMARY KEY,
+	config MEDIUMTEXT NOT NULL
+)`
+
+	sqlInsertConfig = `INSERT IGNORE INTO review_audit_config (hash, config) VALUES (?, ?)`
+
+	sqlQueryConfig = `SELECT config FROM review_audit_config WHERE hash = ?`
+
+	sqlInsert = `INSERT INTO review_audit
+	(created_at, org, repo, number, kind, actor, detail, state, config_hash)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	sqlSelect = `SELECT created_at, org, repo, number, kind, actor, detail, state, config_hash
+	FROM review_audit`
+
+	sqlQuery = sqlSelect + ` WHERE org = ? AND repo = ? AND number = ? ORDER BY id`
+)
+
+type sqlStore struct {
+	db       *sql.DB
+	readOnly bool
+
+	// saved is the hashes of config snapshots saved by this store.
+	saved sync.Map
+}
+
+// newMySQLStore creates the tables if they don't exist, unless it is
+// read-only, which may be opened by the user without the privilege.
+func newMySQLStore(dsn string, readOnly bool) (*sqlStore, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	// it is needed to scan the created_at to time.Time
+	cfg.ParseTime = true
+
+	return newSQLStore("mysql", cfg.FormatDSN(), readOnly)
+}
+
+func newSQLStore(driver, dsn string, readOnly bool) (*sqlStore, error) {
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if !readOnly {
+		for _, stmt := range []string{sqlCreateTable, sqlCreateConfigTable} {
+			if _, err := db.Exec(stmt); err != nil {
+				db.Close()
+				return nil, err
+			}
+		}
+	}
+
+	return &sqlStore{db: db, readOnly: readOnly}, nil
+}
+
+func (s *sqlStore) Append(r Record) error {
+	if s.readOnly {
+		return fmt.Errorf("the audit store is read-only")
+	}
+
+	_, err := s.db.Exec(
+		sqlInsert, r.Time.UTC(), r.Org, r.Repo, r.Number, r.Kind,
+		r.Actor, r.Detail, string(r.State), r.ConfigHash,
+	)
+
+	return err
+}
+
+func (s *sqlStore) Query(org, repo string, number int32) ([]Record, error) {
+	return s.query(sqlQuery, org, repo, number)
+}
+
+func (s *sqlStore) List(f Filter) ([]Record, error) {
+	conds := []string{"created_at >= ?"}
+	args := []interface{}{f.Since.UTC()}
+
+	if f.Org != "" {
+		conds = append(conds, "org = ?")
+		args = append(args, f.Org)
+	}
+
+	if f.Repo != "" {
+		conds = append(conds, "repo = ?")
+		args = append(args, f.Repo)
+	}
+
+	return s.query(
+		sqlSelect+" WHERE "+strings.Join(conds, " AND ")+" ORDER BY id",
+		args...,
+	)
+}
+
+func (s *sqlStore) query(q string, args ...interface{}) ([]Record, error) {
+	rows, err := s.db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var r []Record
+	for rows.Next() {
+		item := Record{}
+		var state string
+
+		err := rows.Scan(
+			&item.Time, &item.Org, &item.Repo, &item.Number, &item.Kind,
+			&item.Actor, &item.Detail, &state, &item.ConfigHash,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if state != "" {
+			item.State = []byte(state)
+		}
+
+		r = append(r, item)
+	}
+
+	return r, rows.Err()
+}
+
+func (s *sqlStore) SaveConfig(c ConfigSnapshot) error {
+	if s.readOnly {
+		return fmt.Errorf("the audit store is read-only")
+	}
+
+	if _, ok := s.saved.Load(c.Hash); ok {
+		return nil
+	}
+
+	_, err := s.db.Exec(sqlInsertConfig, c.Hash, string(c.Config))
+	if err == nil {
+		s.saved.Store(c.Hash, struct{}{})
+	}
+
+	return err
+}
+
+func (s *sqlStore) Config(hash string) (json.RawMessage, error) {
+	var v string
+
+	err := s.db.QueryRow(sqlQueryConfig, hash).Scan(&v)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return json.RawMessage(v), nil
+}
+
+func (s *sqlStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,109 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	KindCommand      = "command"
+	KindLabelAdded   = "label_added"
+	KindLabelRemoved = "label_removed"
+	KindReviewGuide  = "review_guide"
+
+	StoreFile  = "file"
+	StoreMySQL = "mysql"
+)
+
+// Record is a single entry of audit trail of a pull-request.
+type Record struct {
+	Time   time.Time `json:"time"`
+	Org    string    `json:"org"`
+	Repo   string    `json:"repo"`
+	Number int32     `json:"number"`
+
+	// Kind is the kind of event, such as the review command and label change.
+	Kind string `json:"kind"`
+
+	// Actor is the one who caused the event.
+	Actor string `json:"actor,omitempty"`
+
+	// Detail is the command, labels or the content of Review Guide.
+	Detail string `json:"detail,omitempty"`
+
+	// State is the review state computed when the event happened.
+	State json.RawMessage `json:"state,omitempty"`
+
+	// ConfigHash is the hash of config which was in force when the event
+	// happened. It is same as the one returned by the admin api, and the
+	// config itself can be got by Store.Config.
+	ConfigHash string `json:"config_hash,omitempty"`
+}
+
+// ConfigSnapshot is the config which was in force, keyed by its hash.
+type ConfigSnapshot struct {
+	Hash   string          `json:"hash"`
+	Config json.RawMessage `json:"config"`
+}
+
+// Filter selects the records. The empty field matches all.
+type Filter struct {
+	Org   string
+	Repo  string
+	Since time.Time
+}
+
+func (f Filter) match(r *Record) bool {
+	return (f.Org == "" || f.Org == r.Org) &&
+		(f.Repo == "" || f.Repo == r.Repo) &&
+		!r.Time.Before(f.Since)
+}
+
+// Store is an append-only store of audit records.
+type Store interface {
+	Append(Record) error
+	// Query returns the audit trail of a pull-request.
+	Query(org, repo string, number int32) ([]Record, error)
+	// List returns the records matched by filter in the order of appending.
+	List(Filter) ([]Record, error)
+	// SaveConfig saves the snapshot of config if it is not saved before.
+	SaveConfig(ConfigSnapshot) error
+	// Config returns the snapshot of config by its hash. It returns nil
+	// if it is not found.
+	Config(hash string) (json.RawMessage, error)
+	Close() error
+}
+
+// NewStore creates the store by its kind. The path is the file path
+// for file store and the dsn for mysql store.
+func NewStore(kind, path string) (Store, error) {
+	if path == "" {
+		return nil, fmt.Errorf("missing path of audit store")
+	}
+
+	switch kind {
+	case StoreFile:
+		return newFileStore(path)
+	case StoreMySQL:
+		return newMySQLStore(path, false)
+	}
+
+	return nil, fmt.Errorf("unknown audit store: %s", kind)
+}
+
+// NewReadOnlyStore creates the store to query the records only.
+func NewReadOnlyStore(kind, path string) (Store, error) {
+	if path == "" {
+		return nil, fmt.Errorf("missing path of audit store")
+	}
+
+	switch kind {
+	case StoreFile:
+		return &fileStore{path: path}, nil
+	case StoreMySQL:
+		return newMySQLStore(path, true)
+	}
+
+	return nil, fmt.Errorf("unknown audit store: %s", kind)
+}
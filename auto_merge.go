@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/opensourceways/community-robot-lib/giteeclient"
+	sdk "github.com/opensourceways/go-gitee/gitee"
+)
+
+const (
+	autoMergeTitle = "### Auto Merge"
+
+	mergeMethodMerge  = "merge"
+	mergeMethodSquash = "squash"
+	mergeMethodRebase = "rebase"
+)
+
+type autoMergeConfig struct {
+	// Method is the merge method, merge, squash or rebase. The default is merge.
+	Method string `json:"method,omitempty"`
+
+	// RequiredLabels is the labels which must be present besides lgtm and approved.
+	RequiredLabels []string `json:"required_labels,omitempty"`
+
+	// BlockingLabels is the labels which prevent the PR from being merged,
+	// for example `hold`. The request-change label always blocks.
+	BlockingLabels []string `json:"blocking_labels,omitempty"`
+}
+
+func (c *autoMergeConfig) setDefault() {
+	if c != nil && c.Method == "" {
+		c.Method = mergeMethodMerge
+	}
+}
+
+func (c *autoMergeConfig) validate() error {
+	if c == nil {
+		return nil
+	}
+
+	switch c.Method {
+	case mergeMethodMerge, mergeMethodSquash, mergeMethodRebase:
+		return nil
+	}
+
+	return fmt.Errorf("unknown merge method: %s", c.Method)
+}
+
+// unmergeableReasons returns the reasons why the PR can't be merged.
+// ciPassed is whether the CI of head commit passed, see isCIPassedOnHead.
+func unmergeableReasons(pr prInfoOnAPI, cfg *botConfig, ciPassed bool) []string {
+	var r []string
+
+	if !pr.pr.Mergeable {
+		r = append(r, "It is not mergeable, maybe there are conflicts with the target branch.")
+	}
+
+	var required, blocking []string
+	if cfg.AutoMerge != nil {
+		required, blocking = cfg.AutoMerge.RequiredLabels, cfg.AutoMerge.BlockingLabels
+	}
+
+	for _, l := range append([]string{labelLGTM, labelApproved}, required...) {
+		if !pr.hasLabel(l) {
+			r = append(r, fmt.Sprintf("It needs label **%s**.", l))
+		}
+	}
+
+	for _, l := range append([]string{labelRequestChange}, blocking...) {
+		if pr.hasLabel(l) {
+			r = append(r, fmt.Sprintf("It has label **%s**.", l))
+		}
+	}
+
+	if !ciPassed {
+		r = append(r, "The CI of the latest commit has not passed.")
+	}
+
+	if cfg.Draft.isDraft(pr) {
+		r = append(r, "It is a draft.")
+	}
+
+	return r
+}
+
+// autoMerge merges the PR which passed review. It fetches the latest PR
+// to make sure that it is still the one reviewed and can be merged.
+func (pa PostAction) autoMerge(botName string) error {
+	cfg := pa.cfg
+	if cfg.AutoMerge == nil {
+		return nil
+	}
+
+	org, repo := pa.pr.info.getOrgAndRepo()
+	number := pa.pr.info.getNumber()
+
+	v, err := pa.c.GetGiteePullRequest(org, repo, number)
+	if err != nil {
+		return err
+	}
+
+	pr := newPRInfoOnAPI(org, repo, &v)
+	if !pr.isOpen() {
+		return nil
+	}
+
+	if sha := pa.pr.info.getHeadSHA(); pr.getHeadSHA() != sha {
+		pa.log.Infof("skip auto merge, the head is changed from %s to %s", sha, pr.getHeadSHA())
+		return nil
+	}
+
+	ciPassed, err := pa.c.isCIPassedOnHead(pr, cfg.CI, time.Time{})
+	if err != nil {
+		return err
+	}
+
+	if reasons := unmergeableReasons(pr, cfg, ciPassed); len(reasons) > 0 {
+		return pa.writeAutoMergeComment(
+			"It can't be merged automatically because:\n- "+strings.Join(reasons, "\n- "), botName,
+		)
+	}
+
+	err = pa.c.MergePR(org, repo, number, sdk.PullRequestMergePutParam{
+		MergeMethod: cfg.AutoMerge.Method,
+	})
+	if err != nil {
+		pa.log.WithError(err).Error("auto merge")
+
+		return pa.writeAutoMergeComment(
+			fmt.Sprintf("It failed to merge automatically, err: %s", err.Error()), botName,
+		)
+	}
+
+	return nil
+}
+
+// writeAutoMergeComment comments the failure of auto merge if it is
+// different from the last one.
+func (pa PostAction) writeAutoMergeComment(s, botName string) error {
+	info := pa.pr.info
+	org, repo := info.getOrgAndRepo()
+
+	comments, err := pa.c.ListPRComments(org, repo, info.getNumber())
+	if err != nil {
+		return err
+	}
+
+	s = fmt.Sprintf("%s\n\n%s", autoMergeTitle, s)
+
+	if v := giteeclient.FindBotComment(comments, botName, isAutoMergeComment); len(v) > 0 {
+		giteeclient.SortBotComments(v)
+
+		if v[len(v)-1].Body == s {
+			return nil
+		}
+	}
+
+	return pa.c.CreatePRComment(org, repo, info.getNumber(), s)
+}
+
+func isAutoMergeComment(c string) bool {
+	return strings.HasPrefix(c, autoMergeTitle)
+}
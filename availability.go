@@ -0,0 +1,312 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opensourceways/community-robot-lib/giteeclient"
+	"github.com/opensourceways/repo-owners-cache/repoowners"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	cmdOOO  = "OOO"
+	cmdBack = "BACK"
+
+	dateLayout = "2006-01-02"
+)
+
+type absenceConfig struct {
+	// Login is the gitee account of the person who is unavailable.
+	Login string `json:"login" required:"true"`
+
+	// From is the first day of absence, the format is 2006-01-02.
+	From string `json:"from" required:"true"`
+
+	// To is the last day of absence, the format is 2006-01-02.
+	To string `json:"to" required:"true"`
+
+	from time.Time
+	to   time.Time
+}
+
+func (a *absenceConfig) validate() error {
+	if a.Login == "" {
+		return fmt.Errorf("missing login of absence")
+	}
+
+	from, err := time.Parse(dateLayout, a.From)
+	if err != nil {
+		return fmt.Errorf("invalid from of absence for %s, err:%s", a.Login, err.Error())
+	}
+
+	to, err := time.Parse(dateLayout, a.To)
+	if err != nil {
+		return fmt.Errorf("invalid to of absence for %s, err:%s", a.Login, err.Error())
+	}
+
+	if to.Before(from) {
+		return fmt.Errorf("to must not be before from of absence for %s", a.Login)
+	}
+
+	a.from = from
+	// the whole last day is included.
+	a.to = to.AddDate(0, 0, 1)
+
+	return nil
+}
+
+func (a absenceConfig) isActive(t time.Time) bool {
+	return !t.Before(a.from) && t.Before(a.to)
+}
+
+// oooStore keeps the absences which people declared by themselves
+// through the /ooo command. It will be saved to a local file if the
+// path is set, so that it survives the restart of robot.
+type oooStore struct {
+	lock  sync.RWMutex
+	path  string
+	items map[string]time.Time
+}
+
+func newOOOStore(path string) (*oooStore, error) {
+	s := &oooStore{path: path, items: map[string]time.Time{}}
+	if path == "" {
+		return s, nil
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, err
+	}
+
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, &s.items); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// set marks login as unavailable until the time. Zero time means
+// it is unavailable until the person comments /back.
+func (s *oooStore) set(login string, until time.Time) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.items[login] = until
+
+	return s.save()
+}
+
+func (s *oooStore) remove(login string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.items[login]; !ok {
+		return nil
+	}
+
+	delete(s.items, login)
+
+	return s.save()
+}
+
+func (s *oooStore) unavailable(t time.Time) sets.String {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	r := sets.NewString()
+	for login, until := range s.items {
+		if until.IsZero() || t.Before(until) {
+			r.Insert(login)
+		}
+	}
+
+	return r
+}
+
+// save writes the items to file after removing the expired ones.
+func (s *oooStore) save() error {
+	now := time.Now()
+	for login, until := range s.items {
+		if !until.IsZero() && !now.Before(until) {
+			delete(s.items, login)
+		}
+	}
+
+	if s.path == "" {
+		return nil
+	}
+
+	b, err := json.Marshal(s.items)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(s.path, b, 0644)
+}
+
+func (bot *robot) unavailablePeople(cfg *botConfig) sets.String {
+	now := time.Now()
+
+	r := sets.NewString()
+	if bot.ooo != nil {
+		r = bot.ooo.unavailable(now)
+	}
+
+	for i := range cfg.absences {
+		if item := &cfg.absences[i]; item.isActive(now) {
+			r.Insert(normalizeLogin(item.Login))
+		}
+	}
+
+	return r
+}
+
+func (bot *robot) handleOOOComment(e *noteEventInfo, log *logrus.Entry) error {
+	if bot.ooo == nil {
+		return nil
+	}
+
+	commenter := e.normalizedCommenter()
+
+	var tip string
+	if e.cmds.Has(cmdBack) {
+		if err := bot.ooo.remove(commenter); err != nil {
+			return err
+		}
+
+		tip = "Welcome back. You will be suggested as reviewer or approver again."
+	} else {
+		now := time.Now()
+
+		until, err := parseOOOCommand(e.GetComment().GetBody(), now)
+		if err != nil {
+			tip = fmt.Sprintf(
+				"The argument of `/ooo` is invalid, it should be the last day of absence which is not before today, like `/ooo %s`.",
+				now.Format(dateLayout),
+			)
+		} else {
+			if err := bot.ooo.set(commenter, until); err != nil {
+				return err
+			}
+
+			tip = "You will not be suggested as reviewer or approver until you comment `/back`."
+			if !until.IsZero() {
+				tip = fmt.Sprintf(
+					"You will not be suggested as reviewer or approver until %s.",
+					until.AddDate(0, 0, -1).Format(dateLayout),
+				)
+			}
+		}
+	}
+
+	log.Infof("%s updated the availability", commenter)
+
+	org, repo := e.GetOrgRepo()
+
+	return bot.client.CreatePRComment(
+		org, repo, e.GetPRNumber(),
+		giteeclient.GenResponseWithReference(e.NoteEvent, tip),
+	)
+}
+
+// parseOOOCommand returns the time until which the commenter is
+// unavailable. Zero time means there is no end day. The last day is
+// in local time and must not be before the day of now.
+func parseOOOCommand(comment string, now time.Time) (time.Time, error) {
+	for _, match := range commandRegex.FindAllStringSubmatch(comment, -1) {
+		if strings.ToUpper(match[1]) != cmdOOO {
+			continue
+		}
+
+		arg := strings.TrimSpace(match[2])
+		if arg == "" {
+			return time.Time{}, nil
+		}
+
+		t, err := time.ParseInLocation(dateLayout, arg, time.Local)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		until := t.AddDate(0, 0, 1)
+		if !now.Before(until) {
+			return time.Time{}, fmt.Errorf("the last day of absence is before today")
+		}
+
+		return until, nil
+	}
+
+	return time.Time{}, nil
+}
+
+// availableOwner hides the unavailable people from the OWNERS. When all
+// the leaf approvers or reviewers of a file are unavailable, the ones of
+// parent directory will be used instead.
+type availableOwner struct {
+	repoowners.RepoOwner
+
+	unavailable sets.String
+}
+
+func newAvailableOwner(owner repoowners.RepoOwner, unavailable sets.String) repoowners.RepoOwner {
+	if unavailable.Len() == 0 {
+		return owner
+	}
+
+	return availableOwner{RepoOwner: owner, unavailable: unavailable}
+}
+
+func (a availableOwner) Approvers(path string) sets.String {
+	return a.RepoOwner.Approvers(path).Difference(a.unavailable)
+}
+
+func (a availableOwner) Reviewers(path string) sets.String {
+	return a.RepoOwner.Reviewers(path).Difference(a.unavailable)
+}
+
+func (a availableOwner) LeafApprovers(path string) sets.String {
+	return a.leafWithFallback(path, a.RepoOwner.LeafApprovers, a.RepoOwner.FindApproverOwnersForFile)
+}
+
+func (a availableOwner) LeafReviewers(path string) sets.String {
+	return a.leafWithFallback(path, a.RepoOwner.LeafReviewers, a.RepoOwner.FindReviewersOwnersForFile)
+}
+
+func (a availableOwner) leafWithFallback(
+	path string,
+	leaf func(string) sets.String,
+	ownersDir func(string) string,
+) sets.String {
+	for p := path; ; {
+		if v := leaf(p).Difference(a.unavailable); v.Len() > 0 {
+			return v
+		}
+
+		dir := ownersDir(p)
+		if dir == "" || dir == "." {
+			return sets.NewString()
+		}
+
+		p = parentDir(dir)
+	}
+}
+
+func parentDir(dir string) string {
+	if i := strings.LastIndex(dir, "/"); i >= 0 {
+		return dir[:i]
+	}
+	return ""
+}
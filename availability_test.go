@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseOOOCommand(t *testing.T) {
+	now := time.Date(2022, 3, 10, 15, 0, 0, 0, time.Local)
+
+	cases := []struct {
+		comment string
+		expect  time.Time
+		invalid bool
+	}{
+		{"/ooo", time.Time{}, false},
+		{"/ooo 2022-03-10", time.Date(2022, 3, 11, 0, 0, 0, 0, time.Local), false},
+		{"/ooo 2022-03-20", time.Date(2022, 3, 21, 0, 0, 0, 0, time.Local), false},
+		{"/ooo 2022-03-09", time.Time{}, true},
+		{"/ooo 03-20", time.Time{}, true},
+	}
+
+	for _, c := range cases {
+		until, err := parseOOOCommand(c.comment, now)
+		if (err != nil) != c.invalid {
+			t.Errorf("%s: expect invalid=%v, but got err=%v", c.comment, c.invalid, err)
+			continue
+		}
+
+		if !until.Equal(c.expect) {
+			t.Errorf("%s: expect %v, but got %v", c.comment, c.expect, until)
+		}
+	}
+}
+
+func TestOOOStoreSave(t *testing.T) {
+	s := &oooStore{items: map[string]time.Time{}}
+
+	if err := s.set("a", time.Now().Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.set("b", time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := s.items["a"]; ok {
+		t.Error("expect the expired one to be removed")
+	}
+
+	if _, ok := s.items["b"]; !ok {
+		t.Error("expect the one without end day to be kept")
+	}
+}
@@ -30,6 +30,10 @@ func (bot *robot) handleCanReviewComment(e *noteEventInfo, cfg *botConfig, log *
 		return f("Please, sign cla first")
 	}
 
+	if cfg.Draft.isDraft(prInfo) {
+		return f("The PR is a draft, please make it ready for review first")
+	}
+
 	label := cfg.CI.LabelForBasicCIPassed
 
 	if !cfg.CI.NoCI && label != "" && !prInfo.hasLabel(label) {
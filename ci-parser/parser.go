@@ -92,6 +92,27 @@ func (t CITable) GetEachJobComment(c string) ([]string, error) {
 	return nil, fmt.Errorf("empty table")
 }
 
+// ParseJobResults returns the result column of each job. Unlike
+// ParseCIComment, it fails if any row of table can't be parsed.
+func (t CITable) ParseJobResults(c string) ([]string, error) {
+	rows, err := t.GetEachJobComment(c)
+	if err != nil {
+		return nil, err
+	}
+
+	r := make([]string, 0, len(rows))
+	for i, row := range rows {
+		v, err := t.parseJobResult(row)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %s", i+1, err.Error())
+		}
+
+		r = append(r, strings.TrimSpace(v))
+	}
+
+	return r, nil
+}
+
 // parseJobResult return the single job result.
 func (t CITable) parseJobResult(s string) (string, error) {
 	if n := numOfColumns(s); n != t.totleColumnNum {
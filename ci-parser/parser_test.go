@@ -186,3 +186,15 @@ func TestFSSuccess(t *testing.T) {
 		expectFinalStatus: testStatusSuccess,
 	})
 }
+
+func TestParseJobResults(t *testing.T) {
+	v, err := testImpl.ParseJobResults("| Check Name | Result | Details |\n| --- | --- | --- |\n| job1 | job succeeded. | details |\n| job2 | job failed. | details |")
+	if err != nil || len(v) != 2 || v[0] != "job succeeded." || v[1] != "job failed." {
+		t.Errorf("expect 2 results, but got: %v, err: %v", v, err)
+	}
+
+	_, err = testImpl.ParseJobResults("| Check Name | Result | Details |\n| --- | --- | --- |\n| job1 | job succeeded. |\n| job2 | job failed. | details |")
+	if err == nil || err.Error() != "row 1: invalid job comment" {
+		t.Errorf("expect an err of row 1, but got: %v", err)
+	}
+}
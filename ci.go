@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/opensourceways/go-gitee/gitee"
 	"github.com/sirupsen/logrus"
@@ -59,14 +60,61 @@ func canHandleCIEvent(e *gitee.NoteEvent, cfg ciConfig) (bool, error) {
 	return cfg.Job.isCISuccess(e.GetComment().GetBody(), cfg.NumberOfTestCases)
 }
 
+// isCIPassedOnHead checks the latest CI comment which is updated after the
+// head commit was made and after the time since, so the result of an old
+// commit or an old run is not used.
+func (c ghclient) isCIPassedOnHead(pr iPRInfo, cfg ciConfig, since time.Time) (bool, error) {
+	if cfg.NoCI {
+		return true, nil
+	}
+
+	org, repo := pr.getOrgAndRepo()
+
+	t, err := c.getPRCodeUpdateTime(org, repo, pr.getHeadSHA())
+	if err != nil {
+		return false, err
+	}
+
+	comments, err := c.ListPRComments(org, repo, pr.getNumber())
+	if err != nil {
+		return false, err
+	}
+
+	if since.After(t) {
+		t = since
+	}
+
+	latest, body := t, ""
+	for i := range comments {
+		item := &comments[i]
+		if !cfg.Job.CITable.IsCIComment(item.Body) {
+			continue
+		}
+
+		if ut, err := time.Parse(time.RFC3339, item.UpdatedAt); err == nil && ut.After(latest) {
+			latest, body = ut, item.Body
+		}
+	}
+
+	if body == "" {
+		return false, nil
+	}
+
+	return cfg.Job.isCISuccess(body, cfg.NumberOfTestCases)
+}
+
 func (bot *robot) handleCIStatusComment(e *gitee.NoteEvent, cfg *botConfig, log *logrus.Entry) error {
 	if b, err := canHandleCIEvent(e, cfg.CI); !b {
 		return err
 	}
 
+	if err := bot.onCIPassed(prInfoOnNoteEvent{e}, cfg, log); err != nil {
+		log.WithError(err).Error("merge the head of merge queue")
+	}
+
 	org, repo := e.GetOrgRepo()
 
-	owner, err := bot.genRepoOwner(org, repo, e.GetPRBaseRef())
+	owner, err := bot.genRepoOwner(org, repo, e.GetPRBaseRef(), cfg)
 	if err != nil {
 		return err
 	}
@@ -82,25 +130,33 @@ func (bot *robot) handleCIStatusComment(e *gitee.NoteEvent, cfg *botConfig, log
 		return err
 	}
 
-	stats := &reviewStats{
-		pr:        &pr,
-		cfg:       cfg.Review,
-		reviewers: owner.AllReviewers(),
+	stats, err := bot.newReviewStats(&pr, owner, cfg)
+	if err != nil {
+		return err
 	}
 
 	rs, r := info.doStats(stats, bot.botName)
 
-	if rs.IsEmpty() {
+	if rs.hasNoCommand() {
 		return bot.readyToReview(prInfo, cfg, log)
 	}
 
+	bot.scheduleRecheck(prInfo, cfg, r, log)
+
 	pa := PostAction{
 		c:                bot.client,
 		cfg:              cfg,
 		owner:            owner,
 		log:              log,
 		pr:               &pr,
-		isStartingReview: true,
+		unavailable:      bot.unavailablePeople(cfg),
+		ranker:           bot.newExpertiseRanker(cfg),
+		audit:            bot.newAuditRecorder(prInfo, e.GetCommenter(), cfg, log),
+		isStartingReview: !cfg.Draft.isDraft(prInfo) && !bot.isBlockedByOwnersReport(info.comments, cfg),
+		isDraft:          cfg.Draft.isDraft(prInfo),
+		queue:            bot.newQueueUpdater(prInfo, cfg, log),
+		notifier:         bot.newNotifier(prInfo, e.GetCommenter(), cfg, log),
+		publisher:        bot.newStatePublisher(prInfo, e.GetCommenter(), log),
 	}
 
 	return pa.do(info.reviewGuides(bot.botName), "", rs, r, bot.botName)
@@ -132,3 +188,17 @@ func (pr prInfoOnNoteEvent) getAuthor() string {
 func (pr prInfoOnNoteEvent) getHeadSHA() string {
 	return pr.e.GetPRHeadSha()
 }
+
+func (pr prInfoOnNoteEvent) getTitle() string {
+	if v := pr.e.GetPullRequest(); v != nil {
+		return v.Title
+	}
+	return ""
+}
+
+func (pr prInfoOnNoteEvent) isDraft() bool {
+	if v := pr.e.GetPullRequest(); v != nil {
+		return v.Draft
+	}
+	return false
+}
@@ -7,6 +7,9 @@ import (
 	sdk "github.com/opensourceways/go-gitee/gitee"
 )
 
+// opLogOfRemoving is the word in the operation log of removing a label.
+const opLogOfRemoving = "删除"
+
 type ghclient struct {
 	iClient
 }
@@ -20,6 +23,30 @@ func (c ghclient) getPRCodeUpdateTime(org, repo, headSHA string) (time.Time, err
 	return v.Commit.Committer.Date, nil
 }
 
+// getLabelAddedTime returns the latest time when the label was added to
+// the PR, which is recorded by gitee in the operation logs. It returns
+// zero time if it is not found.
+func (c ghclient) getLabelAddedTime(org, repo string, number int32, label string) (time.Time, error) {
+	logs, err := c.ListPROperationLogs(org, repo, number)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var r time.Time
+	for i := range logs {
+		item := &logs[i]
+		if !strings.Contains(item.Content, label) || strings.Contains(item.Content, opLogOfRemoving) {
+			continue
+		}
+
+		if t, err := time.Parse(time.RFC3339, item.CreatedAt); err == nil && t.After(r) {
+			r = t
+		}
+	}
+
+	return r, nil
+}
+
 func (c ghclient) getPullRequestChanges(org, repo string, number int32) ([]string, error) {
 	filenames, err := c.GetPullRequestChanges(org, repo, number)
 	if err != nil {
@@ -46,6 +73,32 @@ func (c ghclient) listCollaborators(org, repo string) ([]string, error) {
 	return r, nil
 }
 
+func (c ghclient) listCommitsOfPath(org, repo, branch, path string, since time.Time) ([]historyCommit, error) {
+	v, err := c.ListRepoCommits(org, repo, branch, path, since)
+	if err != nil {
+		return nil, err
+	}
+
+	r := make([]historyCommit, 0, len(v))
+	for i := range v {
+		item := &v[i]
+
+		hc := historyCommit{}
+		if item.Commit != nil && item.Commit.Committer != nil {
+			hc.t = item.Commit.Committer.Date
+		}
+		if item.Author != nil {
+			hc.author = normalizeLogin(item.Author.Login)
+		}
+		if item.Committer != nil {
+			hc.committer = normalizeLogin(item.Committer.Login)
+		}
+
+		r = append(r, hc)
+	}
+	return r, nil
+}
+
 func getAssignees(pr *sdk.PullRequestHook) []string {
 	if pr == nil {
 		return nil
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const commitHistoryTTL = 6 * time.Hour
+
+type iCommitHistory interface {
+	listCommitsOfPath(org, repo, branch, path string, since time.Time) ([]historyCommit, error)
+	// cachedCommitsOfPath is same as listCommitsOfPath but never calls the api.
+	cachedCommitsOfPath(org, repo, branch, path string, since time.Time) []historyCommit
+}
+
+type historyCommit struct {
+	author    string
+	committer string
+	t         time.Time
+}
+
+type historyItem struct {
+	commits   []historyCommit
+	since     time.Time
+	fetchedAt time.Time
+}
+
+// commitHistory caches the commits of paths, so the history of the same
+// files is not fetched again for each event of PR.
+type commitHistory struct {
+	cli ghclient
+	ttl time.Duration
+
+	lock  sync.Mutex
+	items map[string]historyItem
+}
+
+func newCommitHistory(cli iClient, ttl time.Duration) *commitHistory {
+	return &commitHistory{
+		cli:   ghclient{cli},
+		ttl:   ttl,
+		items: map[string]historyItem{},
+	}
+}
+
+func historyKey(org, repo, branch, path string) string {
+	return fmt.Sprintf("%s/%s/%s:%s", org, repo, branch, path)
+}
+
+func (h *commitHistory) cachedCommitsOfPath(org, repo, branch, path string, since time.Time) []historyCommit {
+	v, _ := h.get(historyKey(org, repo, branch, path), since)
+
+	return v
+}
+
+func (h *commitHistory) listCommitsOfPath(
+	org, repo, branch, path string, since time.Time,
+) ([]historyCommit, error) {
+	key := historyKey(org, repo, branch, path)
+
+	if v, ok := h.get(key, since); ok {
+		return v, nil
+	}
+
+	v, err := h.cli.listCommitsOfPath(org, repo, branch, path, since)
+	if err != nil {
+		return nil, err
+	}
+
+	h.lock.Lock()
+	for k, item := range h.items {
+		if time.Since(item.fetchedAt) > h.ttl {
+			delete(h.items, k)
+		}
+	}
+	h.items[key] = historyItem{commits: v, since: since, fetchedAt: time.Now()}
+	h.lock.Unlock()
+
+	return v, nil
+}
+
+func (h *commitHistory) get(key string, since time.Time) ([]historyCommit, bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	item, ok := h.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	if time.Since(item.fetchedAt) > h.ttl {
+		delete(h.items, key)
+		return nil, false
+	}
+
+	if item.since.After(since) {
+		return nil, false
+	}
+
+	r := make([]historyCommit, 0, len(item.commits))
+	for _, c := range item.commits {
+		if !c.t.Before(since) {
+			r = append(r, c)
+		}
+	}
+
+	return r, true
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/opensourceways/community-robot-lib/config"
 )
@@ -9,11 +10,37 @@ import (
 type configuration struct {
 	ConfigItems []botConfig `json:"config_items,omitempty"`
 
+	// Defaults is the config shared by all the config items. The config
+	// of orgs and config items override it field by field.
+	Defaults *repoConfig `json:"defaults,omitempty"`
+
+	// Orgs is the config of each org which overrides the Defaults. The
+	// config items of repos in the org override it field by field.
+	Orgs []orgConfig `json:"orgs,omitempty"`
+
 	// CommandsEndpoint is the endpoint which enumerates the usage of commands.
 	CommandsEndpoint string `json:"commands_endpoint" required:"true"`
 
 	// Doc describes useful information about review process of PR.
 	Doc string `json:"doc" required:"true"`
+
+	// Absences lists the periods when people are unavailable.
+	// They will not be suggested as reviewers or approvers during that time.
+	Absences []absenceConfig `json:"absences,omitempty"`
+
+	// Teams defines the named groups of people. The name of team can be
+	// used in OWNERS file and `/cc` command in place of the members.
+	Teams []teamConfig `json:"teams,omitempty"`
+
+	teams *teams `json:"-"`
+
+	// items is the effective config items merged from all the layers.
+	items []botConfig `json:"-"`
+
+	// itemsErr is the error of merging the layers.
+	itemsErr error `json:"-"`
+
+	raw *rawLayers `json:"-"`
 }
 
 func (c *configuration) configFor(org, repo string) *botConfig {
@@ -21,16 +48,13 @@ func (c *configuration) configFor(org, repo string) *botConfig {
 		return nil
 	}
 
-	items := c.ConfigItems
+	items := c.items
 	v := make([]config.IRepoFilter, len(items))
 	for i := range items {
 		v[i] = &items[i]
 	}
 
 	if i := config.Find(org, repo, v); i >= 0 {
-		items[i].doc = c.Doc
-		items[i].commandsEndpoint = c.CommandsEndpoint
-
 		return &items[i]
 	}
 
@@ -42,6 +66,10 @@ func (c *configuration) Validate() error {
 		return nil
 	}
 
+	if c.itemsErr != nil {
+		return c.itemsErr
+	}
+
 	if c.CommandsEndpoint == "" {
 		return fmt.Errorf("missing commands_endpoint")
 	}
@@ -50,7 +78,23 @@ func (c *configuration) Validate() error {
 		return fmt.Errorf("missing doc")
 	}
 
-	items := c.ConfigItems
+	for i := range c.Absences {
+		if err := c.Absences[i].validate(); err != nil {
+			return err
+		}
+	}
+
+	for i := range c.Teams {
+		if err := c.Teams[i].validate(); err != nil {
+			return err
+		}
+	}
+
+	if err := validateOrgs(c.Orgs); err != nil {
+		return err
+	}
+
+	items := c.items
 	for i := range items {
 		if err := items[i].validate(); err != nil {
 			return err
@@ -65,7 +109,10 @@ func (c *configuration) SetDefault() {
 		return
 	}
 
-	Items := c.ConfigItems
+	c.teams = newTeams(c.Teams)
+	c.items, c.itemsErr = c.genEffectiveItems()
+
+	Items := c.items
 	for i := range Items {
 		Items[i].setDefault()
 	}
@@ -74,11 +121,21 @@ func (c *configuration) SetDefault() {
 type botConfig struct {
 	config.RepoFilter
 
+	repoConfig
+
+	doc              string          `json:"-"`
+	commandsEndpoint string          `json:"-"`
+	absences         []absenceConfig `json:"-"`
+	teams            *teams          `json:"-"`
+}
+
+// repoConfig is the config of repo which can be set at each layer.
+type repoConfig struct {
 	CI ciConfig `json:"ci"`
 
 	Review reviewConfig `json:"review"`
 
-	CLALabel string `json:"cla_label" required:"true"`
+	CLALabel string `json:"cla_label,omitempty"`
 
 	// NeedWelcome specifies whether to add welcome comment.
 	NeedWelcome bool `json:"need_welcome,omitempty"`
@@ -86,14 +143,37 @@ type botConfig struct {
 	// NoParentOwners decision whether the comment permission includes the parent directory owners
 	NoParentOwners bool `json:"no_parent_owners,omitempty"`
 
-	doc              string `json:"-"`
-	commandsEndpoint string `json:"-"`
+	// BlockCanReviewOnInvalidOwners specifies whether to not add the can-review
+	// label until the errors of OWNERS files changed by the PR are fixed.
+	BlockCanReviewOnInvalidOwners bool `json:"block_can_review_on_invalid_owners,omitempty"`
+
+	// Draft specifies how to recognize the draft PR.
+	Draft draftConfig `json:"draft,omitempty"`
+
+	// AutoMerge specifies how to merge the PR automatically when it passes
+	// review. It is disabled if it is not set.
+	AutoMerge *autoMergeConfig `json:"auto_merge,omitempty"`
+
+	// MergeQueue specifies how to merge the PRs which passed review one by one
+	// for each target branch. It is disabled if it is not set, and the
+	// AutoMerge is ignored if it is set.
+	MergeQueue *mergeQueueConfig `json:"merge_queue,omitempty"`
+
+	// Stale specifies how to remind and escalate the PRs which have no
+	// activity for a long time. It is disabled if it is not set.
+	Stale *staleConfig `json:"stale,omitempty"`
+
+	// Notifications is the sinks to send the review events of PR to.
+	Notifications []notificationConfig `json:"notifications,omitempty"`
 }
 
 func (c *botConfig) setDefault() {
 	if c != nil {
 		c.CI.setDefault()
 		c.Review.setDefault()
+		c.Draft.setDefault()
+		c.AutoMerge.setDefault()
+		c.MergeQueue.setDefault()
 	}
 }
 
@@ -102,6 +182,11 @@ func (c *botConfig) validate() error {
 		return nil
 	}
 
+	// it is not required at each layer, but must be set at one of them
+	if c.CLALabel == "" {
+		return fmt.Errorf("missing cla_label of %s", strings.Join(c.Repos, ","))
+	}
+
 	if err := c.CI.validate(); err != nil {
 		return err
 	}
@@ -110,5 +195,23 @@ func (c *botConfig) validate() error {
 		return err
 	}
 
+	if err := c.Stale.validate(); err != nil {
+		return err
+	}
+
+	if err := c.AutoMerge.validate(); err != nil {
+		return err
+	}
+
+	if err := c.MergeQueue.validate(c.CI.NoCI); err != nil {
+		return err
+	}
+
+	for i := range c.Notifications {
+		if err := c.Notifications[i].validate(); err != nil {
+			return err
+		}
+	}
+
 	return c.RepoFilter.Validate()
 }
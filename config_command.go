@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/yaml"
+
+	ciparser "github.com/opensourceways/robot-gitee-review-trigger/ci-parser"
+	"github.com/opensourceways/robot-gitee-review-trigger/schema"
+)
+
+const (
+	subCmdCheckConfig  = "check-config"
+	subCmdConfigSchema = "config-schema"
+)
+
+type ciComments []string
+
+func (c *ciComments) String() string {
+	return strings.Join(*c, ",")
+}
+
+func (c *ciComments) Set(v string) error {
+	*c = append(*c, v)
+	return nil
+}
+
+type checkConfigOptions struct {
+	configFile string
+	repo       string
+	ciComments ciComments
+}
+
+func (o *checkConfigOptions) validate() error {
+	if o.configFile == "" {
+		return fmt.Errorf("missing config-file")
+	}
+
+	if o.repo != "" && len(strings.Split(o.repo, "/")) != 2 {
+		return fmt.Errorf("repo must be the format of org/repo")
+	}
+
+	return nil
+}
+
+// runCheckConfig loads the config file as the robot does, parses the
+// sample CI comments with the CI table of each config item, and writes
+// the effective config to stdout.
+func runCheckConfig(args []string) {
+	var o checkConfigOptions
+
+	fs := flag.NewFlagSet(subCmdCheckConfig, flag.ExitOnError)
+	fs.StringVar(&o.configFile, "config-file", "", "the config file to check.")
+	fs.StringVar(&o.repo, "repo", "", "the repo of format org/repo to show the effective config. All the config items are shown if it is empty.")
+	fs.Var(&o.ciComments, "ci-comment", "the file of sample CI comment to parse. It can be set multiple times.")
+	_ = fs.Parse(args)
+
+	if err := o.validate(); err != nil {
+		logrus.WithError(err).Fatal("Invalid options")
+	}
+
+	cfg, err := loadConfigFile(o.configFile)
+	if err != nil {
+		logrus.WithError(err).Fatal("Invalid config")
+	}
+
+	comments := make(map[string]string, len(o.ciComments))
+	for _, f := range o.ciComments {
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			logrus.WithError(err).Fatalf("read %s", f)
+		}
+		comments[f] = string(b)
+	}
+
+	failed := checkCIComments(os.Stdout, cfg, comments)
+
+	if err := writeEffectiveConfig(os.Stdout, cfg, o.repo); err != nil {
+		logrus.WithError(err).Fatal("write the effective config")
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// loadConfigFile loads the config in the same steps as the framework,
+// but the unknown fields are reported.
+func loadConfigFile(path string) (*configuration, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkUnknownFields(b); err != nil {
+		return nil, err
+	}
+
+	cfg := new(configuration)
+	if err := yaml.Unmarshal(b, cfg); err != nil {
+		return nil, err
+	}
+
+	cfg.SetDefault()
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// checkUnknownFields decodes the config strictly without the layers, since
+// the unknown fields are not reported when the config decodes itself.
+func checkUnknownFields(b []byte) error {
+	j, err := yaml.YAMLToJSONStrict(b)
+	if err != nil {
+		return err
+	}
+
+	type layers configuration
+
+	d := json.NewDecoder(bytes.NewReader(j))
+	d.DisallowUnknownFields()
+
+	return d.Decode(new(layers))
+}
+
+// checkCIComments parses each comment with the CI table of each config
+// item. It returns true if there is a comment which can't be parsed.
+func checkCIComments(w io.Writer, cfg *configuration, comments map[string]string) bool {
+	files := make([]string, 0, len(comments))
+	for f := range comments {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+
+	failed := false
+
+	for i := range cfg.items {
+		item := &cfg.items[i]
+		if item.CI.NoCI || item.CI.Job == nil {
+			continue
+		}
+
+		for _, f := range files {
+			s, ok := checkCIComment(item.CI, comments[f])
+			fmt.Fprintf(w, "config item %d (%s), %s: %s\n", i, strings.Join(item.Repos, ","), f, s)
+
+			if !ok {
+				failed = true
+			}
+		}
+	}
+
+	return failed
+}
+
+func checkCIComment(cfg ciConfig, comment string) (string, bool) {
+	job := cfg.Job
+
+	if !job.CITable.IsCIComment(comment) {
+		return "it is not a CI comment, check the title of ci_table", false
+	}
+
+	// every row must be parsed, otherwise the jobs are miscounted silently
+	results, err := job.CITable.ParseJobResults(comment)
+	if err != nil {
+		return fmt.Sprintf("parse failed, %s", err.Error()), false
+	}
+
+	status, err := ciparser.ParseCIComment(job.newCIParser(), comment)
+	if err != nil {
+		return fmt.Sprintf("parse failed, %s", err.Error()), false
+	}
+
+	return fmt.Sprintf(
+		"%d jobs, %d of them successful, %d successful jobs required, CI passed: %v",
+		len(results), len(status), cfg.NumberOfTestCases, len(status) == cfg.NumberOfTestCases,
+	), true
+}
+
+func writeEffectiveConfig(w io.Writer, cfg *configuration, orgRepo string) error {
+	var r interface{}
+
+	if orgRepo != "" {
+		v := strings.Split(orgRepo, "/")
+
+		item := cfg.configFor(v[0], v[1])
+		if item == nil {
+			return fmt.Errorf("no config for %s", orgRepo)
+		}
+
+		r = item.effective()
+	} else {
+		items := make([]effectiveConfig, len(cfg.items))
+		for i := range cfg.items {
+			items[i] = cfg.items[i].effective()
+		}
+
+		r = items
+	}
+
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "%s\n", b)
+
+	return err
+}
+
+// runConfigSchema writes the JSON Schema of config to stdout.
+func runConfigSchema() {
+	b, err := json.MarshalIndent(schema.Generate(botName, &configuration{}), "", "  ")
+	if err != nil {
+		logrus.WithError(err).Fatal("generate the schema of config")
+	}
+
+	fmt.Printf("%s\n", b)
+}
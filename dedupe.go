@@ -0,0 +1,125 @@
+package main
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"sync"
+	"time"
+
+	sdk "github.com/opensourceways/go-gitee/gitee"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	eventTTL      = 30 * time.Minute
+	readyEventTTL = 24 * time.Hour
+)
+
+// eventCache remembers the events handled recently, so that the ones
+// resent by gitee on timeout will be skipped.
+type eventCache struct {
+	lock     sync.Mutex
+	ttl      time.Duration
+	seen     map[string]time.Time
+	handling sets.String
+}
+
+func newEventCache(ttl time.Duration) *eventCache {
+	return &eventCache{
+		ttl:      ttl,
+		seen:     map[string]time.Time{},
+		handling: sets.NewString(),
+	}
+}
+
+// begin returns false if the event is being handled or has been handled
+// successfully in ttl. Otherwise, end must be called after handling it.
+func (c *eventCache) begin(key string) bool {
+	now := time.Now()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for k, t := range c.seen {
+		if now.Sub(t) > c.ttl {
+			delete(c.seen, k)
+		}
+	}
+
+	if _, ok := c.seen[key]; ok || c.handling.Has(key) {
+		return false
+	}
+
+	c.handling.Insert(key)
+
+	return true
+}
+
+// end records the event as handled if it succeeded. Otherwise, the event
+// will be handled again when it is resent.
+func (c *eventCache) end(key string, succeeded bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.handling.Delete(key)
+
+	if succeeded {
+		c.seen[key] = time.Now()
+	}
+}
+
+// prEventKey includes the time of PR update, so the same action repeated
+// later, such as closing the PR again, is not taken as the resent one.
+func prEventKey(e *sdk.PullRequestEvent) string {
+	org, repo := e.GetOrgRepo()
+
+	updatedAt := ""
+	if pr := e.GetPullRequest(); pr != nil {
+		updatedAt = pr.UpdatedAt
+	}
+
+	return fmt.Sprintf(
+		"pr/%s/%s/%d/%s/%s/%s/%s/%s",
+		org, repo, e.GetPRNumber(), e.GetAction(), e.GetActionDesc(),
+		e.GetPRHeadSha(), e.GetPRBaseRef(), updatedAt,
+	)
+}
+
+// noteEventKey is keyed on the comment id and the body, because the
+// CI comment may be updated with new status.
+func noteEventKey(e *sdk.NoteEvent) string {
+	org, repo := e.GetOrgRepo()
+	c := e.GetComment()
+
+	return fmt.Sprintf(
+		"note/%s/%s/%d/%d/%x",
+		org, repo, e.GetPRNumber(), c.Id, sha1.Sum([]byte(c.Body)),
+	)
+}
+
+// prInfoWithLabels overrides the labels of PR which may be stale
+// in the resent event.
+type prInfoWithLabels struct {
+	iPRInfo
+	labels sets.String
+}
+
+func (pr prInfoWithLabels) hasLabel(l string) bool {
+	return pr.labels.Has(l)
+}
+
+func (bot *robot) withLatestLabels(pr iPRInfo) (iPRInfo, error) {
+	org, repo := pr.getOrgAndRepo()
+
+	v, err := bot.client.GetPRLabels(org, repo, pr.getNumber())
+	if err != nil {
+		return nil, err
+	}
+
+	labels := sets.NewString()
+	for i := range v {
+		labels.Insert(v[i].Name)
+	}
+
+	return prInfoWithLabels{iPRInfo: pr, labels: labels}, nil
+}
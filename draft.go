@@ -0,0 +1,133 @@
+package main
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+const labelWIP = "wip"
+
+type draftConfig struct {
+	// TitlePrefixes is the prefixes of title which mark the PR as draft.
+	// They are case insensitive. The default is `[WIP]` and `WIP:`.
+	TitlePrefixes []string `json:"title_prefixes,omitempty"`
+
+	// Label is the label which marks the PR as draft. The default is `wip`.
+	Label string `json:"label,omitempty"`
+}
+
+func (c *draftConfig) setDefault() {
+	if len(c.TitlePrefixes) == 0 {
+		c.TitlePrefixes = []string{"[WIP]", "WIP:"}
+	}
+
+	if c.Label == "" {
+		c.Label = labelWIP
+	}
+}
+
+// isDraft checks whether the PR is a draft by the draft flag, the
+// prefix of title or the label. The review does not start for a draft.
+func (c *draftConfig) isDraft(pr iPRInfo) bool {
+	if pr.isDraft() || pr.hasLabel(c.Label) {
+		return true
+	}
+
+	title := strings.ToUpper(strings.TrimSpace(pr.getTitle()))
+	for _, p := range c.TitlePrefixes {
+		if strings.HasPrefix(title, strings.ToUpper(p)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// draftStates remembers whether the PRs were drafts when their events were
+// handled last time, so that only the real changes of draft state are handled.
+// The states are kept in memory, so the first event of each PR after the
+// robot restarts is taken as a change.
+type draftStates struct {
+	lock   sync.Mutex
+	states map[string]bool
+}
+
+func newDraftStates() *draftStates {
+	return &draftStates{states: map[string]bool{}}
+}
+
+func (s *draftStates) isChanged(key string, isDraft bool) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	v, ok := s.states[key]
+	return !ok || v != isDraft
+}
+
+func (s *draftStates) set(key string, isDraft bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.states[key] = isDraft
+}
+
+func (s *draftStates) remove(key string) {
+	if s == nil {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.states, key)
+}
+
+// handleDraftChange starts the review when the PR leaves draft state,
+// and stops it when the PR becomes a draft.
+func (bot *robot) handleDraftChange(pr iPRInfo, cfg *botConfig, log *logrus.Entry) error {
+	isDraft := cfg.Draft.isDraft(pr)
+
+	key := prKey(pr)
+	if !bot.drafts.isChanged(key, isDraft) {
+		return nil
+	}
+
+	// The state is recorded only if it is handled successfully, so that
+	// it will be handled again when the event is resent.
+	err := bot.onDraftChanged(pr, isDraft, cfg, log)
+	if err == nil {
+		bot.drafts.set(key, isDraft)
+	}
+
+	return err
+}
+
+func (bot *robot) onDraftChanged(pr iPRInfo, isDraft bool, cfg *botConfig, log *logrus.Entry) error {
+
+	// The review labels are changed by the robot itself, so only the PR
+	// without any of them is checked whether to start the review.
+	isReviewing := pr.hasLabel(labelCanReview) || pr.hasLabel(labelLGTM) ||
+		pr.hasLabel(labelApproved) || pr.hasLabel(labelRequestChange)
+
+	// All the review labels are removed as a push does, since the review
+	// restarts when the PR leaves draft state.
+	if isDraft && isReviewing {
+		bot.dropFromQueue(pr, cfg, log, "")
+
+		if _, err := bot.removeReviewLabels(pr, cfg, nil, restartedReviewState(), log); err != nil {
+			return err
+		}
+
+		return bot.deleteReviewNotification(pr)
+	}
+
+	if !isDraft && !isReviewing && (cfg.CI.NoCI || pr.hasLabel(cfg.CI.LabelForCIPassed)) {
+		org, repo := pr.getOrgAndRepo()
+
+		return bot.syncPR(org, repo, pr.getNumber(), cfg, log)
+	}
+
+	return nil
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+type historyRankConfig struct {
+	// Days is the number of days of commit history to look back.
+	Days int `json:"days,omitempty"`
+
+	// HalfLifeDays is the number of days after which the weight of
+	// a commit becomes half of a new one.
+	HalfLifeDays int `json:"half_life_days,omitempty"`
+
+	// MaxFiles is the max number of changed files whose history will be
+	// fetched, in order to limit the calls of gitee api.
+	MaxFiles int `json:"max_files,omitempty"`
+}
+
+func (c *historyRankConfig) setDefault() {
+	if c == nil {
+		return
+	}
+
+	if c.Days <= 0 {
+		c.Days = 180
+	}
+
+	if c.HalfLifeDays <= 0 {
+		c.HalfLifeDays = 30
+	}
+
+	if c.MaxFiles <= 0 {
+		c.MaxFiles = 20
+	}
+}
+
+// expertiseSignal records how a candidate has worked on the changed files.
+type expertiseSignal struct {
+	// commits is the number of commits authored by the candidate.
+	commits int
+
+	// reviews is the number of commits merged by the candidate but
+	// authored by others.
+	reviews int
+
+	last  time.Time
+	score float64
+}
+
+func (s *expertiseSignal) String() string {
+	return fmt.Sprintf(
+		"score=%.2f commits=%d reviews=%d last=%s",
+		s.score, s.commits, s.reviews, s.last.Format(dateLayout),
+	)
+}
+
+// expertiseExplanation is the signal of a candidate shown by the admin api.
+type expertiseExplanation struct {
+	Login   string  `json:"login"`
+	Score   float64 `json:"score"`
+	Commits int     `json:"commits"`
+	Reviews int     `json:"reviews"`
+	Last    string  `json:"last"`
+}
+
+// reviewerPicker picks n candidates. All of them are picked if n <= 0.
+type reviewerPicker func(candidates sets.String, n int) []string
+
+const historyFetchWorkers = 5
+
+type expertiseRanker struct {
+	cli iCommitHistory
+	cfg historyRankConfig
+
+	// cachedOnly specifies whether to use the cached history only.
+	cachedOnly bool
+}
+
+func (bot *robot) newExpertiseRanker(cfg *botConfig) *expertiseRanker {
+	if bot.history == nil || cfg.Review.RankByHistory == nil {
+		return nil
+	}
+
+	return &expertiseRanker{cli: bot.history, cfg: *cfg.Review.RankByHistory}
+}
+
+func (r *expertiseRanker) signals(
+	org, repo, branch string, files []string, log *logrus.Entry,
+) map[string]*expertiseSignal {
+	now := time.Now()
+	since := now.AddDate(0, 0, -r.cfg.Days)
+	halfLife := float64(r.cfg.HalfLifeDays) * 24
+
+	if len(files) > r.cfg.MaxFiles {
+		files = files[:r.cfg.MaxFiles]
+	}
+
+	history := r.fetch(org, repo, branch, files, since, log)
+
+	signals := map[string]*expertiseSignal{}
+	get := func(login string) *expertiseSignal {
+		v, ok := signals[login]
+		if !ok {
+			v = &expertiseSignal{}
+			signals[login] = v
+		}
+		return v
+	}
+
+	for _, commits := range history {
+		for i := range commits {
+			c := &commits[i]
+			w := math.Pow(0.5, now.Sub(c.t).Hours()/halfLife)
+
+			if c.author != "" {
+				s := get(c.author)
+				s.commits++
+				s.score += w
+				if c.t.After(s.last) {
+					s.last = c.t
+				}
+			}
+
+			if c.committer != "" && c.committer != c.author {
+				s := get(c.committer)
+				s.reviews++
+				s.score += w / 2
+				if c.t.After(s.last) {
+					s.last = c.t
+				}
+			}
+		}
+	}
+
+	return signals
+}
+
+// fetch lists the commits of files concurrently.
+func (r *expertiseRanker) fetch(
+	org, repo, branch string, files []string, since time.Time, log *logrus.Entry,
+) [][]historyCommit {
+	history := make([][]historyCommit, len(files))
+
+	if r.cachedOnly {
+		for i := range files {
+			history[i] = r.cli.cachedCommitsOfPath(org, repo, branch, files[i], since)
+		}
+
+		return history
+	}
+
+	tokens := make(chan struct{}, historyFetchWorkers)
+
+	var wg sync.WaitGroup
+	for i := range files {
+		wg.Add(1)
+		tokens <- struct{}{}
+
+		go func(i int) {
+			defer func() {
+				<-tokens
+				wg.Done()
+			}()
+
+			v, err := r.cli.listCommitsOfPath(org, repo, branch, files[i], since)
+			if err != nil {
+				log.WithError(err).Warnf("list commits of %s", files[i])
+				return
+			}
+			history[i] = v
+		}(i)
+	}
+	wg.Wait()
+
+	return history
+}
+
+// explain returns the signals of the candidates in the order of score.
+func (r *expertiseRanker) explain(
+	org, repo, branch string, files []string, log *logrus.Entry,
+) []expertiseExplanation {
+	signals := r.signals(org, repo, branch, files, log)
+
+	v := make([]expertiseExplanation, 0, len(signals))
+	for login, s := range signals {
+		v = append(v, expertiseExplanation{
+			Login:   login,
+			Score:   math.Round(s.score*100) / 100,
+			Commits: s.commits,
+			Reviews: s.reviews,
+			Last:    s.last.Format(dateLayout),
+		})
+	}
+
+	sort.Slice(v, func(i, j int) bool {
+		if v[i].Score != v[j].Score {
+			return v[i].Score > v[j].Score
+		}
+		return v[i].Login < v[j].Login
+	})
+
+	return v
+}
+
+// picker returns a picker which prefers the candidates who worked on
+// the changed files more recently and more often.
+func (r *expertiseRanker) picker(
+	org, repo, branch string, files []string, log *logrus.Entry,
+) reviewerPicker {
+	signals := r.signals(org, repo, branch, files, log)
+
+	score := func(login string) float64 {
+		if s, ok := signals[login]; ok {
+			return s.score
+		}
+		return 0
+	}
+
+	return func(candidates sets.String, n int) []string {
+		list := candidates.List()
+		sort.SliceStable(list, func(i, j int) bool {
+			return score(list[i]) > score(list[j])
+		})
+
+		if n > 0 && len(list) > n {
+			list = list[:n]
+		}
+
+		desc := make([]string, 0, len(list))
+		for _, item := range list {
+			if s, ok := signals[item]; ok {
+				desc = append(desc, fmt.Sprintf("%s(%s)", item, s))
+			} else {
+				desc = append(desc, fmt.Sprintf("%s(score=0)", item))
+			}
+		}
+		log.Infof("Ranked reviewers by history: %s", strings.Join(desc, ", "))
+
+		return list
+	}
+}
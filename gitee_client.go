@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/antihax/optional"
+	"github.com/opensourceways/community-robot-lib/giteeclient"
+	sdk "github.com/opensourceways/go-gitee/gitee"
+	"golang.org/x/oauth2"
+)
+
+const commitsPerPage = 100
+
+// giteeClient adds the apis which are not provided by the giteeclient.
+type giteeClient struct {
+	giteeclient.Client
+
+	ac *sdk.APIClient
+}
+
+// tokenSource reads the token by the secret agent for each request,
+// so that the rotated token is used.
+type tokenSource func() []byte
+
+func (f tokenSource) Token() (*oauth2.Token, error) {
+	return &oauth2.Token{AccessToken: string(f())}, nil
+}
+
+func newGiteeClient(getToken func() []byte) giteeClient {
+	// oauth2.NewClient is not used, because it caches the token which
+	// has no expiry forever.
+	conf := sdk.NewConfiguration()
+	conf.HTTPClient = &http.Client{
+		Transport: &oauth2.Transport{Source: tokenSource(getToken)},
+	}
+
+	return giteeClient{
+		Client: giteeclient.NewClient(getToken),
+		ac:     sdk.NewAPIClient(conf),
+	}
+}
+
+// ListRepoCommits lists the commits of the path on the branch since the time.
+func (c giteeClient) ListRepoCommits(org, repo, branch, path string, since time.Time) ([]sdk.RepoCommit, error) {
+	opt := sdk.GetV5ReposOwnerRepoCommitsOpts{
+		Sha:     optional.NewString(branch),
+		Path:    optional.NewString(path),
+		Since:   optional.NewString(since.Format(time.RFC3339)),
+		PerPage: optional.NewInt32(commitsPerPage),
+	}
+
+	var r []sdk.RepoCommit
+
+	for p := int32(1); ; p++ {
+		opt.Page = optional.NewInt32(p)
+
+		v, _, err := c.ac.RepositoriesApi.GetV5ReposOwnerRepoCommits(context.Background(), org, repo, &opt)
+		if err != nil {
+			return nil, fmt.Errorf("list commits of %s: %w", path, err)
+		}
+
+		r = append(r, v...)
+
+		if len(v) < commitsPerPage {
+			break
+		}
+	}
+
+	return r, nil
+}
@@ -3,9 +3,16 @@ module github.com/opensourceways/robot-gitee-review-trigger
 go 1.16
 
 require (
+	github.com/antihax/optional v1.0.0
+	github.com/go-sql-driver/mysql v1.5.0
+	github.com/nats-io/nats.go v1.11.0
 	github.com/opensourceways/community-robot-lib v0.0.0-20220118064921-28924d0a1246
 	github.com/opensourceways/go-gitee v0.0.0-20220118023153-0c41490fb43b
 	github.com/opensourceways/repo-owners-cache v0.0.0-20220111071329-b9e81e7cc107
+	github.com/segmentio/kafka-go v0.4.47
 	github.com/sirupsen/logrus v1.8.1
+	golang.org/x/oauth2 v0.0.0-20211104180415-d3ed0bb246c8
+	gopkg.in/yaml.v3 v3.0.1
 	k8s.io/apimachinery v0.23.1
+	sigs.k8s.io/yaml v1.3.0
 )
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -22,16 +23,31 @@ const (
 	reviewStatusLGTM       = "is added **lgtm** label"
 	reviewStatusApproved   = "is added **approved** label"
 	reviewStatusPassReview = "**Passes Review**"
+
+	timeLayoutOfGuide = "2006-01-02 15:04 MST"
 )
 
-func newNotificationComment(rs *reviewSummary, s, botName string) notificationComment {
-	return notificationComment{rs: rs, oldTips: s, botName: botName}
+func newNotificationComment(rs *reviewSummary, s, botName string, t *teams) notificationComment {
+	return notificationComment{rs: rs, oldTips: s, botName: botName, teams: t}
 }
 
 type notificationComment struct {
 	rs      *reviewSummary
+	rr      reviewResult
 	oldTips string
 	botName string
+	teams   *teams
+
+	// ownersTips flags the changes of OWNERS files.
+	ownersTips string
+
+	// queueTips is the position of PR in the merge queue.
+	queueTips string
+}
+
+func (n notificationComment) withResult(r reviewResult) notificationComment {
+	n.rr = r
+	return n
 }
 
 func (n notificationComment) genApproveTips(num int, approvers []string) string {
@@ -39,7 +55,7 @@ func (n notificationComment) genApproveTips(num int, approvers []string) string
 		"%s, it still needs **%d** approvers to comment /approve.\nI suggest these approvers( %s ) to approve your PR.\nYou can assign the PR to them by writing a comment like this `/assign @%s`. Please, replace `%s` with the correct approver's name.",
 		notificationApprovePart2,
 		num,
-		toReviewerList(approvers),
+		n.suggestedList(approvers),
 		n.botName,
 		n.botName,
 	)
@@ -52,7 +68,7 @@ func (n notificationComment) genLGTMTips(num int, suggestedReviewers []string) s
 	if len(suggestedReviewers) > 0 {
 		s2 := fmt.Sprintf(
 			"\nI suggest these reviewers( %s ) to review your codes.\nYou can ask them to review by writing a comment like this `@%s, Could you take a look at this PR, thanks!`. Please, replace `%s` with correct reviewer's name",
-			toReviewerList(suggestedReviewers),
+			n.suggestedList(suggestedReviewers),
 			n.botName,
 			n.botName,
 		)
@@ -72,8 +88,21 @@ func (n notificationComment) genLGTMTips(num int, suggestedReviewers []string) s
 
 }
 
+func (n notificationComment) withFiles(files []string) notificationComment {
+	n.ownersTips = ownersFilesTips(files)
+	return n
+}
+
+func (n notificationComment) withQueue(tips string) notificationComment {
+	n.queueTips = tips
+	return n
+}
+
 func (n notificationComment) startReviewComment(reviewers []string) string {
 	s := n.genLGTMTips(len(reviewers), reviewers)
+	if n.ownersTips != "" {
+		s = notificationLineSpliter + n.ownersTips + s
+	}
 	return fmt.Sprintf("%s %s.%s", notificationTitle, reviewStatusStart, s)
 }
 
@@ -83,7 +112,7 @@ func (n notificationComment) reviewingComment(num int, reviewers []string) strin
 		tips = fmt.Sprintf(
 			"%sReviewers who writed a comment of `/lbtm` are: %s. Please make changes if it needs.",
 			notificationLGTMPart2, toReviewerList(v),
-		)
+		) + n.reasonsOf(v)
 	}
 
 	if s := n.reviewInfo(); s != "" {
@@ -97,21 +126,23 @@ func (n notificationComment) reviewingComment(num int, reviewers []string) strin
 
 func (n notificationComment) rejectComment() string {
 	return fmt.Sprintf(
-		"%s %s.%sIt is rejected by: %s. Please see the comments left by them and do more changes.",
+		"%s %s.%sIt is rejected by: %s. Please see the comments left by them and do more changes.%s",
 		notificationTitle,
 		reviewStatusRejected,
 		notificationLineSpliter,
 		toReviewerList(n.rs.disagreedApprovers),
+		n.reasonsOf(n.rs.disagreedApprovers),
 	)
 }
 
 func (n notificationComment) requestChangeComment() string {
 	return fmt.Sprintf(
-		"%s %s.%sIt is requested change by: %s. Please see the comments left by them and do more changes.",
+		"%s %s.%sIt is requested change by: %s. Please see the comments left by them and do more changes.%s",
 		notificationTitle,
 		reviewStatusChange,
 		notificationLineSpliter,
 		toReviewerList(n.rs.disagreedReviewers),
+		n.reasonsOf(n.rs.disagreedReviewers),
 	)
 }
 
@@ -171,9 +202,49 @@ func (n notificationComment) reviewInfo() string {
 	}
 
 	if s != "" && s1 != "" {
-		return s + notificationLineSpliter + s1
+		s = s + notificationLineSpliter + s1
+	} else {
+		s += s1
+	}
+
+	add := func(s1 string) {
+		if s != "" {
+			s += notificationLineSpliter
+		}
+		s += s1
+	}
+
+	if n.ownersTips != "" {
+		add(n.ownersTips)
+	}
+
+	if n.rr.unmetRule != "" {
+		add(n.rr.unmetRule)
+	}
+
+	if v := ignoredVotersTips(rs.ignoredVoters); v != "" {
+		add(v)
+	}
+
+	if v := n.rr.overruledRejecters; len(v) > 0 {
+		add(fmt.Sprintf(
+			"The `/reject` of %s is overruled by the rejection policy of this repository.",
+			toReviewerList(v),
+		))
+	}
+
+	if n.queueTips != "" {
+		add(n.queueTips)
 	}
-	return s + s1
+
+	if t := n.rr.waitUntil; !t.IsZero() {
+		add(fmt.Sprintf(
+			"The review should last for a while, the **approved** label will be added after %s.",
+			t.Format(timeLayoutOfGuide),
+		))
+	}
+
+	return s
 }
 
 func (n notificationComment) getPart2OfApproved(suggestedApprovers []string) string {
@@ -210,10 +281,65 @@ func convertReviewers(v []string) []string {
 	return rs
 }
 
+// reasonsOf lists the reasons of people who disagreed, one for each line.
+func (n notificationComment) reasonsOf(v []string) string {
+	b := new(strings.Builder)
+	for _, item := range v {
+		if r := n.rs.reasons[item]; r != "" {
+			fmt.Fprintf(b, "%s- [*%s*](https://gitee.com/%s): %s", notificationLineSpliter, item, item, r)
+		}
+	}
+
+	return b.String()
+}
+
+// ignoredVotersTips explains whose review commands are ignored, one line for each reason.
+func ignoredVotersTips(ignored map[string]string) string {
+	voters := map[string][]string{}
+	for login, reason := range ignored {
+		voters[reason] = append(voters[reason], login)
+	}
+
+	reasons := make([]string, 0, len(voters))
+	for reason := range voters {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+
+	v := make([]string, 0, len(reasons))
+	for _, reason := range reasons {
+		sort.Strings(voters[reason])
+
+		v = append(v, fmt.Sprintf(
+			"The review commands of %s are ignored, because it is %s.",
+			toReviewerList(voters[reason]), reason,
+		))
+	}
+
+	return strings.Join(v, notificationLineSpliter)
+}
+
 func toReviewerList(v []string) string {
 	return strings.Join(convertReviewers(v), notificationReviewersSpliter)
 }
 
+// suggestedList is same as toReviewerList except that it appends
+// the teams which each one belongs to.
+func (n notificationComment) suggestedList(v []string) string {
+	if n.teams.isEmpty() {
+		return toReviewerList(v)
+	}
+
+	rs := convertReviewers(v)
+	for i, item := range v {
+		if ts := n.teams.teamsOf(item); len(ts) > 0 {
+			rs[i] += fmt.Sprintf(" of *%s*", strings.Join(ts, notificationReviewersSpliter))
+		}
+	}
+
+	return strings.Join(rs, notificationReviewersSpliter)
+}
+
 func containsSuggestedApprover(c string) bool {
 	return strings.Contains(c, notificationApprovePart2)
 }
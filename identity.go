@@ -0,0 +1,141 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/opensourceways/repo-owners-cache/repoowners"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	collaboratorsTTL = 10 * time.Minute
+
+	ignoredReasonBot             = "bot account"
+	ignoredReasonNotCollaborator = "not a collaborator"
+)
+
+// collaboratorCache caches the collaborators of repos to avoid
+// listing them for each event.
+type collaboratorCache struct {
+	lock  sync.Mutex
+	ttl   time.Duration
+	items map[string]collaboratorItem
+}
+
+type collaboratorItem struct {
+	logins sets.String
+	t      time.Time
+}
+
+func newCollaboratorCache(ttl time.Duration) *collaboratorCache {
+	return &collaboratorCache{ttl: ttl, items: map[string]collaboratorItem{}}
+}
+
+// get returns the cached collaborators if it fails to list them. The result
+// is nil if there is no cache either.
+func (c *collaboratorCache) get(org, repo string, list func() ([]string, error)) (sets.String, error) {
+	key := org + "/" + repo
+
+	c.lock.Lock()
+	item, ok := c.items[key]
+	c.lock.Unlock()
+
+	if ok && time.Since(item.t) < c.ttl {
+		return item.logins, nil
+	}
+
+	v, err := list()
+	if err != nil {
+		return item.logins, err
+	}
+
+	item = collaboratorItem{logins: sets.NewString(v...), t: time.Now()}
+
+	c.lock.Lock()
+	c.items[key] = item
+	c.lock.Unlock()
+
+	return item.logins, nil
+}
+
+// commenterPolicy decides whose review commands are ignored.
+type commenterPolicy struct {
+	bots sets.String
+
+	// collaborators is nil if the commenters are not required to be collaborators.
+	collaborators sets.String
+}
+
+// newCommenterPolicy doesn't fail when it can't list the collaborators,
+// because the review should not be blocked by it. The stale collaborators
+// are used or the check is skipped in that case.
+func (bot *robot) newCommenterPolicy(org, repo string, cfg *botConfig) commenterPolicy {
+	p := commenterPolicy{bots: sets.NewString()}
+	for _, item := range cfg.Review.IgnoredAccounts {
+		p.bots.Insert(normalizeLogin(item))
+	}
+
+	if !cfg.Review.RequireCollaborator {
+		return p
+	}
+
+	v, err := bot.collaborators.get(org, repo, func() ([]string, error) {
+		return bot.client.listCollaborators(org, repo)
+	})
+	if err != nil {
+		logrus.WithError(err).Warnf(
+			"list the collaborators of %s/%s, the cached ones are used: %v", org, repo, v != nil,
+		)
+	}
+
+	p.collaborators = v
+
+	return p
+}
+
+// ignoredReason returns why the review commands of commenter are ignored.
+// It returns empty string if they are not ignored.
+func (p commenterPolicy) ignoredReason(commenter string) string {
+	if p.bots.Has(commenter) {
+		return ignoredReasonBot
+	}
+
+	if p.collaborators != nil && !p.collaborators.Has(commenter) {
+		return ignoredReasonNotCollaborator
+	}
+
+	return ""
+}
+
+func (bot *robot) newReviewStats(pr *pullRequest, owner repoowners.RepoOwner, cfg *botConfig) (*reviewStats, error) {
+	org, repo := pr.info.getOrgAndRepo()
+
+	p := bot.newCommenterPolicy(org, repo, cfg)
+
+	rules := cfg.requiredApprovals()
+
+	// The min review hours are counted from the time recorded by gitee,
+	// because the time of commit is specified by the author.
+	var canReviewAt time.Time
+	if cfg.Review.minReviewHours(pr.files) > 0 {
+		t, err := bot.client.getLabelAddedTime(org, repo, pr.info.getNumber(), labelCanReview)
+		if err != nil {
+			return nil, err
+		}
+		canReviewAt = t
+	}
+
+	return &reviewStats{
+		pr:            pr,
+		cfg:           cfg.Review,
+		rules:         rules,
+		ruleApprovers: rules.approversOf(pr.files),
+		canReviewAt:   canReviewAt,
+		reviewers:     owner.AllReviewers(),
+		rootApprovers: owner.TopLevelApprovers(),
+		commenters:    p,
+		affiliations:  bot.affiliations,
+	}, nil
+}
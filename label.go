@@ -4,12 +4,12 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 )
 
-func updatePRLabel(c ghclient, pr iPRInfo, keep ...string) error {
-	_, err := updateAndReturnRemovedLabels(c, pr, keep...)
-	return err
-}
-
-func updateAndReturnRemovedLabels(c ghclient, pr iPRInfo, keep ...string) ([]string, error) {
+// updateAndReturnChangedLabels keeps the review labels and removes the others.
+// It returns the labels which are changed even if it fails partly, so that
+// the changes can be recorded.
+func updateAndReturnChangedLabels(c ghclient, pr iPRInfo, keep ...string) (
+	added []string, removed []string, err error,
+) {
 	l := labelUpdating{
 		c:  c,
 		pr: pr,
@@ -17,7 +17,8 @@ func updateAndReturnRemovedLabels(c ghclient, pr iPRInfo, keep ...string) ([]str
 
 	mr := multiError()
 
-	if err := l.addLabels(keep...); err != nil {
+	added, err = l.addLabels(keep...)
+	if err != nil {
 		mr.AddError(err)
 	}
 
@@ -25,12 +26,12 @@ func updateAndReturnRemovedLabels(c ghclient, pr iPRInfo, keep ...string) ([]str
 
 	toRemove := all.Delete(keep...).UnsortedList()
 
-	removed, err := l.removeLabels(toRemove)
+	removed, err = l.removeLabels(toRemove)
 	if err != nil {
 		mr.AddError(err)
 	}
 
-	return removed, mr.Err()
+	return added, removed, mr.Err()
 }
 
 type labelUpdating struct {
@@ -38,19 +39,25 @@ type labelUpdating struct {
 	pr iPRInfo
 }
 
-func (l labelUpdating) addLabels(labels ...string) error {
+// addLabels returns the labels added. It is empty if it fails.
+func (l labelUpdating) addLabels(labels ...string) ([]string, error) {
 	pr := l.pr
 
 	toAdd := filterSlice(labels, pr.hasLabel)
 	if len(toAdd) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	org, repo := pr.getOrgAndRepo()
 
-	return l.c.AddMultiPRLabel(org, repo, pr.getNumber(), labels)
+	if err := l.c.AddMultiPRLabel(org, repo, pr.getNumber(), labels); err != nil {
+		return nil, err
+	}
+
+	return toAdd, nil
 }
 
+// removeLabels returns the labels removed. It is empty if it fails.
 func (l labelUpdating) removeLabels(labels []string) ([]string, error) {
 	pr := l.pr
 
@@ -63,7 +70,11 @@ func (l labelUpdating) removeLabels(labels []string) ([]string, error) {
 
 	org, repo := pr.getOrgAndRepo()
 
-	return toRemove, l.c.RemovePRLabels(org, repo, pr.getNumber(), toRemove)
+	if err := l.c.RemovePRLabels(org, repo, pr.getNumber(), toRemove); err != nil {
+		return nil, err
+	}
+
+	return toRemove, nil
 }
 
 func filterSlice(s []string, filter func(string) bool) []string {
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+type orgConfig struct {
+	// Org is the name of org.
+	Org string `json:"org" required:"true"`
+
+	// Doc overrides the global one for the repos of this org.
+	Doc string `json:"doc,omitempty"`
+
+	// CommandsEndpoint overrides the global one for the repos of this org.
+	CommandsEndpoint string `json:"commands_endpoint,omitempty"`
+
+	// Config overrides the Defaults for the repos of this org.
+	// All the repos of org apply it if it is set, even if there is
+	// no config item for them.
+	Config *repoConfig `json:"config,omitempty"`
+}
+
+func validateOrgs(orgs []orgConfig) error {
+	seen := sets.NewString()
+	for i := range orgs {
+		org := orgs[i].Org
+
+		if org == "" || strings.Contains(org, "/") {
+			return fmt.Errorf("invalid org: %s", org)
+		}
+
+		if seen.Has(org) {
+			return fmt.Errorf("duplicate config of org: %s", org)
+		}
+		seen.Insert(org)
+	}
+
+	return nil
+}
+
+// rawLayers keeps the layers of config as they are written, so that the
+// fields which are set to zero value, such as false, can override the lower
+// layers when merging them.
+type rawLayers struct {
+	defaults json.RawMessage
+	orgs     []json.RawMessage
+	items    []json.RawMessage
+}
+
+func (c *configuration) UnmarshalJSON(b []byte) error {
+	type alias configuration
+	if err := json.Unmarshal(b, (*alias)(c)); err != nil {
+		return err
+	}
+
+	var v struct {
+		ConfigItems []json.RawMessage `json:"config_items"`
+		Defaults    json.RawMessage   `json:"defaults"`
+		Orgs        []struct {
+			Config json.RawMessage `json:"config"`
+		} `json:"orgs"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	c.raw = &rawLayers{
+		defaults: v.Defaults,
+		items:    v.ConfigItems,
+		orgs:     make([]json.RawMessage, len(v.Orgs)),
+	}
+	for i := range v.Orgs {
+		c.raw.orgs[i] = v.Orgs[i].Config
+	}
+
+	return nil
+}
+
+// genEffectiveItems merges the config items with the config of orgs and the
+// defaults. The item whose repos belong to several orgs is split for each org,
+// since each org may have its own config.
+func (c *configuration) genEffectiveItems() ([]botConfig, error) {
+	orgs := make(map[string]int, len(c.Orgs))
+	for i := range c.Orgs {
+		orgs[c.Orgs[i].Org] = i
+	}
+
+	var r []botConfig
+
+	for i := range c.ConfigItems {
+		item := &c.ConfigItems[i]
+
+		for _, org := range orgsOf(item.Repos) {
+			j, ok := orgs[org]
+			if !ok {
+				j = -1
+			}
+
+			rc, err := c.mergeLayers(j, i)
+			if err != nil {
+				return nil, fmt.Errorf("merge the config of %s, err: %s", org, err.Error())
+			}
+
+			v := botConfig{repoConfig: rc}
+			v.Repos = filterByOrg(item.Repos, org)
+			v.ExcludedRepos = filterByOrg(item.ExcludedRepos, org)
+
+			r = append(r, c.withGlobal(v, c.orgAt(j)))
+		}
+	}
+
+	for i := range c.Orgs {
+		o := &c.Orgs[i]
+		if o.Config == nil {
+			continue
+		}
+
+		rc, err := c.mergeLayers(i, -1)
+		if err != nil {
+			return nil, fmt.Errorf("merge the config of %s, err: %s", o.Org, err.Error())
+		}
+
+		v := botConfig{repoConfig: rc}
+		v.Repos = []string{o.Org}
+
+		r = append(r, c.withGlobal(v, o))
+	}
+
+	return r, nil
+}
+
+func (c *configuration) orgAt(i int) *orgConfig {
+	if i < 0 {
+		return nil
+	}
+	return &c.Orgs[i]
+}
+
+// mergeLayers merges the defaults, the config of org and the config item in
+// order, field by field. -1 means there is no such layer. The result is
+// decoded from the merged JSON, so it shares no list or map with the layers.
+// The layers must be decoded from JSON, because the fields which are not
+// set can't be told from the zero values after decoding.
+func (c *configuration) mergeLayers(org, item int) (repoConfig, error) {
+	var r repoConfig
+
+	raw := c.raw
+	if raw == nil {
+		return r, fmt.Errorf("the config is not decoded from JSON")
+	}
+
+	var merged interface{} = map[string]interface{}{}
+
+	merge := func(b json.RawMessage) error {
+		if len(b) == 0 {
+			return fmt.Errorf("missing the JSON of a layer")
+		}
+
+		var m interface{}
+		if err := json.Unmarshal(b, &m); err != nil {
+			return err
+		}
+
+		merged = mergeJSON(merged, m)
+
+		return nil
+	}
+
+	if c.Defaults != nil {
+		if err := merge(raw.defaults); err != nil {
+			return r, err
+		}
+	}
+
+	if o := c.orgAt(org); o != nil && o.Config != nil {
+		if err := merge(rawAt(raw.orgs, org)); err != nil {
+			return r, err
+		}
+	}
+
+	if item >= 0 {
+		if err := merge(rawAt(raw.items, item)); err != nil {
+			return r, err
+		}
+	}
+
+	b, err := json.Marshal(merged)
+	if err != nil {
+		return r, err
+	}
+
+	err = json.Unmarshal(b, &r)
+
+	return r, err
+}
+
+func rawAt(v []json.RawMessage, i int) json.RawMessage {
+	if i < len(v) {
+		return v[i]
+	}
+	return nil
+}
+
+// mergeJSON merges src into dst. The objects are merged key by key
+// recursively, and any other value of src, including false, 0, the
+// empty list and null, overrides the one of dst.
+func mergeJSON(dst, src interface{}) interface{} {
+	d, ok := dst.(map[string]interface{})
+	if !ok {
+		return src
+	}
+
+	s, ok := src.(map[string]interface{})
+	if !ok {
+		return src
+	}
+
+	for k, v := range s {
+		if dv, ok := d[k]; ok {
+			d[k] = mergeJSON(dv, v)
+		} else {
+			d[k] = v
+		}
+	}
+
+	return d
+}
+
+func (c *configuration) withGlobal(v botConfig, org *orgConfig) botConfig {
+	v.doc = c.Doc
+	v.commandsEndpoint = c.CommandsEndpoint
+	v.absences = c.Absences
+	v.teams = c.teams
+
+	if org != nil {
+		if org.Doc != "" {
+			v.doc = org.Doc
+		}
+
+		if org.CommandsEndpoint != "" {
+			v.commandsEndpoint = org.CommandsEndpoint
+		}
+	}
+
+	return v
+}
+
+// effectiveConfig is the config applied to the repo, which is shown for inspection.
+type effectiveConfig struct {
+	*botConfig
+
+	Doc              string `json:"doc"`
+	CommandsEndpoint string `json:"commands_endpoint"`
+}
+
+func (c *botConfig) effective() effectiveConfig {
+	return effectiveConfig{
+		botConfig:        c,
+		Doc:              c.doc,
+		CommandsEndpoint: c.commandsEndpoint,
+	}
+}
+
+func orgsOf(repos []string) []string {
+	seen := sets.NewString()
+	r := make([]string, 0, 1)
+
+	for _, item := range repos {
+		if org := strings.Split(item, "/")[0]; !seen.Has(org) {
+			seen.Insert(org)
+			r = append(r, org)
+		}
+	}
+
+	return r
+}
+
+func filterByOrg(repos []string, org string) []string {
+	var r []string
+	for _, item := range repos {
+		if strings.Split(item, "/")[0] == org {
+			r = append(r, item)
+		}
+	}
+
+	return r
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMergeJSON(t *testing.T) {
+	cases := []struct {
+		name   string
+		dst    string
+		src    string
+		expect string
+	}{
+		{"merge objects by key", `{"a":1,"b":{"c":1,"d":1}}`, `{"b":{"d":2},"e":1}`, `{"a":1,"b":{"c":1,"d":2},"e":1}`},
+		{"false overrides true", `{"a":true}`, `{"a":false}`, `{"a":false}`},
+		{"0 overrides", `{"a":{"b":3}}`, `{"a":{"b":0}}`, `{"a":{"b":0}}`},
+		{"list is replaced", `{"a":[1,2]}`, `{"a":[3]}`, `{"a":[3]}`},
+		{"empty list overrides", `{"a":[1,2]}`, `{"a":[]}`, `{"a":[]}`},
+		{"null overrides", `{"a":{"b":1}}`, `{"a":null}`, `{"a":null}`},
+		{"object overrides scalar", `{"a":1}`, `{"a":{"b":1}}`, `{"a":{"b":1}}`},
+	}
+
+	for _, c := range cases {
+		var dst, src, expect interface{}
+		for _, v := range []struct {
+			s string
+			p *interface{}
+		}{{c.dst, &dst}, {c.src, &src}, {c.expect, &expect}} {
+			if err := json.Unmarshal([]byte(v.s), v.p); err != nil {
+				t.Fatalf("%s: %v", c.name, err)
+			}
+		}
+
+		if r := mergeJSON(dst, src); !reflect.DeepEqual(r, expect) {
+			t.Errorf("%s: expect %v, but got %v", c.name, expect, r)
+		}
+	}
+}
+
+func TestGenEffectiveItems(t *testing.T) {
+	b := []byte(`{
+		"defaults": {
+			"cla_label": "d-cla", "need_welcome": true,
+			"review": {"total_number_of_reviewers": 3}, "draft": {"title_prefixes": ["WIP"]}
+		},
+		"orgs": [
+			{"org": "o1", "config": {"cla_label": "o1-cla", "need_welcome": false}},
+			{"org": "o3", "config": {"review": {"total_number_of_reviewers": 0}}}
+		],
+		"config_items": [
+			{"repos": ["o1/a", "o2/b"], "review": {"total_number_of_reviewers": 5}},
+			{"repos": ["o2/c"], "cla_label": "c-cla", "need_welcome": false}
+		]
+	}`)
+
+	cfg := configuration{}
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	items, err := cfg.genEffectiveItems()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type result struct {
+		repos       []string
+		claLabel    string
+		needWelcome bool
+		reviewers   int
+	}
+
+	expect := []result{
+		{[]string{"o1/a"}, "o1-cla", false, 5},
+		{[]string{"o2/b"}, "d-cla", true, 5},
+		{[]string{"o2/c"}, "c-cla", false, 3},
+		{[]string{"o1"}, "o1-cla", false, 3},
+		{[]string{"o3"}, "d-cla", true, 0},
+	}
+
+	if len(items) != len(expect) {
+		t.Fatalf("expect %d items, but got %d", len(expect), len(items))
+	}
+
+	for i := range items {
+		item := &items[i]
+		r := result{item.Repos, item.CLALabel, item.NeedWelcome, item.Review.TotalNumberOfReviewers}
+
+		if !reflect.DeepEqual(r, expect[i]) {
+			t.Errorf("item %d: expect %+v, but got %+v", i, expect[i], r)
+		}
+	}
+
+	// The items merged from the same layers share no list.
+	items[0].Draft.TitlePrefixes[0] = "DRAFT"
+	if v := items[1].Draft.TitlePrefixes; len(v) != 1 || v[0] != "WIP" {
+		t.Errorf("expect the items not to share the list, but got %v", v)
+	}
+}
+
+func TestGenEffectiveItemsWithoutJSON(t *testing.T) {
+	cfg := configuration{
+		Defaults:    &repoConfig{NeedWelcome: true},
+		ConfigItems: []botConfig{{}},
+	}
+	cfg.ConfigItems[0].Repos = []string{"o/r"}
+
+	if _, err := cfg.genEffectiveItems(); err == nil {
+		t.Error("expect an error for the config which is not decoded from JSON")
+	}
+}
@@ -1,23 +1,220 @@
 package main
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 
-	"github.com/opensourceways/community-robot-lib/giteeclient"
 	"github.com/opensourceways/community-robot-lib/logrusutil"
 	liboptions "github.com/opensourceways/community-robot-lib/options"
 	"github.com/opensourceways/community-robot-lib/robot-gitee-framework"
 	"github.com/opensourceways/community-robot-lib/secret"
 	"github.com/opensourceways/repo-owners-cache/grpc/client"
 	"github.com/sirupsen/logrus"
+
+	"github.com/opensourceways/robot-gitee-review-trigger/audit"
+	"github.com/opensourceways/robot-gitee-review-trigger/mq"
+	"github.com/opensourceways/robot-gitee-review-trigger/notify"
+	"github.com/opensourceways/robot-gitee-review-trigger/report"
 )
 
 type options struct {
 	service     liboptions.ServiceOptions
 	gitee       liboptions.GiteeOptions
 	cacheServer string
+	oooFile     string
+	queueFile   string
+	affiliation string
+	audit       auditOptions
+
+	staleCheckInterval time.Duration
+
+	retryAttempts int
+
+	adminPort      int
+	adminTokenPath string
+
+	smtp smtpOptions
+	mq   mqOptions
+}
+
+type mqOptions struct {
+	kind    string
+	address string
+	topic   string
+}
+
+func (o *mqOptions) addFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.kind, "mq-kind", "", "the kind of message queue to publish the review state changes, kafka or nats. It is not published if it is empty.")
+	fs.StringVar(&o.address, "mq-address", "", "the address of message queue. It is the brokers separated by comma for kafka, such as kafka-0:9092,kafka-1:9092, and the servers for nats, such as nats://host:4222 or tls://host:4222.")
+	fs.StringVar(&o.topic, "mq-topic", eventReviewStateChanged, "the topic or subject to publish the review state changes to.")
+}
+
+func (o *mqOptions) validate() error {
+	if o.kind == "" {
+		return nil
+	}
+
+	if o.address == "" && o.kind != mq.KindMemory {
+		return fmt.Errorf("missing mq-address")
+	}
+
+	if o.topic == "" {
+		return fmt.Errorf("missing mq-topic")
+	}
+
+	return nil
+}
+
+func (o *mqOptions) newPublisher() (mq.Publisher, error) {
+	if o.kind == "" {
+		return nil, nil
+	}
+
+	p, err := mq.NewPublisher(o.kind, o.address)
+	if err != nil {
+		return nil, err
+	}
+
+	return newAsyncPublisher(p), nil
+}
+
+type smtpOptions struct {
+	server       string
+	from         string
+	username     string
+	passwordPath string
+}
+
+func (o *smtpOptions) addFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.server, "smtp-server", "", "the address of smtp server to send the notifications by email, such as smtp.example.com:587.")
+	fs.StringVar(&o.from, "smtp-from", "", "the sender of notification email.")
+	fs.StringVar(&o.username, "smtp-username", "", "the username to login the smtp server. It does not login if it is empty.")
+	fs.StringVar(&o.passwordPath, "smtp-password-path", "", "the path of secret file which includes the password of smtp-username.")
+}
+
+func (o *smtpOptions) validate() error {
+	if o.server == "" {
+		return nil
+	}
+
+	if o.from == "" {
+		return fmt.Errorf("missing smtp-from")
+	}
+
+	if o.username != "" && o.passwordPath == "" {
+		return fmt.Errorf("missing smtp-password-path")
+	}
+
+	return nil
+}
+
+// check rejects the email sinks of config if the smtp server is not set,
+// otherwise they only fail when sending.
+func (o *smtpOptions) check(cfg *configuration) error {
+	if o.server != "" || cfg == nil {
+		return nil
+	}
+
+	for i := range cfg.items {
+		item := &cfg.items[i]
+
+		for j := range item.Notifications {
+			if item.Notifications[j].Kind == notify.SinkEmail {
+				return fmt.Errorf(
+					"the email notification of %s requires the smtp-server",
+					strings.Join(item.Repos, ","),
+				)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (o *smtpOptions) secrets() []string {
+	if o.server != "" && o.username != "" {
+		return []string{o.passwordPath}
+	}
+	return nil
+}
+
+func (o *smtpOptions) newMailer(secretAgent *secret.Agent) *notify.Mailer {
+	if o.server == "" {
+		return nil
+	}
+
+	m := &notify.Mailer{
+		Addr:     o.server,
+		From:     o.from,
+		Username: o.username,
+	}
+
+	if o.username != "" {
+		m.Password = strings.TrimSpace(string(secretAgent.GetSecret(o.passwordPath)))
+	}
+
+	return m
+}
+
+type auditOptions struct {
+	store        string
+	file         string
+	mysqlDSNPath string
+	port         int
+}
+
+func (o *auditOptions) addFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.store, "audit-store", "", "the kind of audit store, file or mysql. The audit is disabled if it is empty.")
+	fs.StringVar(&o.file, "audit-file", "", "the file to save the audit records when audit-store is file.")
+	fs.StringVar(&o.mysqlDSNPath, "audit-mysql-dsn-path", "", "the path of secret file which includes the dsn of mysql when audit-store is mysql.")
+	fs.IntVar(&o.port, "audit-port", 0, "the port to serve the query of audit trail and report. It is not served if it is 0.")
+}
+
+func (o *auditOptions) validate() error {
+	switch o.store {
+	case "":
+		return nil
+	case audit.StoreFile:
+		if o.file == "" {
+			return fmt.Errorf("missing audit-file")
+		}
+	case audit.StoreMySQL:
+		if o.mysqlDSNPath == "" {
+			return fmt.Errorf("missing audit-mysql-dsn-path")
+		}
+	default:
+		return fmt.Errorf("unknown audit-store: %s", o.store)
+	}
+
+	return nil
+}
+
+func (o *auditOptions) secrets() []string {
+	if o.store == audit.StoreMySQL {
+		return []string{o.mysqlDSNPath}
+	}
+	return nil
+}
+
+func (o *auditOptions) newStore(secretAgent *secret.Agent, readOnly bool) (audit.Store, error) {
+	path := o.file
+	switch o.store {
+	case "":
+		return nil, nil
+	case audit.StoreMySQL:
+		path = strings.TrimSpace(string(secretAgent.GetSecret(o.mysqlDSNPath)))
+	}
+
+	if readOnly {
+		return audit.NewReadOnlyStore(o.store, path)
+	}
+
+	return audit.NewStore(o.store, path)
 }
 
 func (o *options) Validate() error {
@@ -29,15 +226,53 @@ func (o *options) Validate() error {
 		return fmt.Errorf("cache service address can not be empty")
 	}
 
+	if o.staleCheckInterval <= 0 {
+		return fmt.Errorf("stale-check-interval must be bigger than 0")
+	}
+
+	if (o.adminPort > 0 || o.audit.port > 0) && o.adminTokenPath == "" {
+		return fmt.Errorf("missing admin-token-path")
+	}
+
+	if err := o.audit.validate(); err != nil {
+		return err
+	}
+
+	if err := o.smtp.validate(); err != nil {
+		return err
+	}
+
+	if err := o.mq.validate(); err != nil {
+		return err
+	}
+
 	return o.gitee.Validate()
 }
 
+func (o *options) secrets() []string {
+	v := []string{o.gitee.TokenPath}
+	if o.adminPort > 0 || o.audit.port > 0 {
+		v = append(v, o.adminTokenPath)
+	}
+	return v
+}
+
 func gatherOptions(fs *flag.FlagSet, args ...string) options {
 	var o options
 
 	o.gitee.AddFlags(fs)
 	o.service.AddFlags(fs)
 	fs.StringVar(&o.cacheServer, "cache-server", "", "the cache server address.")
+	fs.StringVar(&o.oooFile, "ooo-file", "", "the file to save the absences declared by /ooo command.")
+	fs.StringVar(&o.queueFile, "merge-queue-file", "", "the file to save the merge queues, so that they survive the restart.")
+	fs.StringVar(&o.affiliation, "affiliation-file", "", "the file which lists the members of each organization.")
+	fs.DurationVar(&o.staleCheckInterval, "stale-check-interval", time.Hour, "the interval to check the stale PRs.")
+	fs.IntVar(&o.retryAttempts, "retry-attempts", 5, "the max attempts to retry the PR whose handling failed. It is not retried if it is 0.")
+	fs.IntVar(&o.adminPort, "admin-port", 0, "the port to serve the admin api. It is not served if it is 0.")
+	fs.StringVar(&o.adminTokenPath, "admin-token-path", "", "the path of secret file which includes the token to access the admin api and the audit api.")
+	o.audit.addFlags(fs)
+	o.smtp.addFlags(fs)
+	o.mq.addFlags(fs)
 
 	_ = fs.Parse(args)
 
@@ -47,13 +282,29 @@ func gatherOptions(fs *flag.FlagSet, args ...string) options {
 func main() {
 	logrusutil.ComponentInit(botName)
 
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case subCmdReport:
+			runReport(os.Args[2:])
+			return
+
+		case subCmdCheckConfig:
+			runCheckConfig(os.Args[2:])
+			return
+
+		case subCmdConfigSchema:
+			runConfigSchema()
+			return
+		}
+	}
+
 	o := gatherOptions(flag.NewFlagSet(os.Args[0], flag.ExitOnError), os.Args[1:]...)
 	if err := o.Validate(); err != nil {
 		logrus.WithError(err).Fatal("Invalid options")
 	}
 
 	secretAgent := new(secret.Agent)
-	if err := secretAgent.Start([]string{o.gitee.TokenPath}); err != nil {
+	if err := secretAgent.Start(append(append(o.secrets(), o.audit.secrets()...), o.smtp.secrets()...)); err != nil {
 		logrus.WithError(err).Fatal("Error starting secret agent.")
 	}
 
@@ -70,14 +321,108 @@ func main() {
 		}
 	}()
 
-	c := giteeclient.NewClient(secretAgent.GetTokenGenerator(o.gitee.TokenPath))
+	c := newGiteeClient(secretAgent.GetTokenGenerator(o.gitee.TokenPath))
 
 	v, err := c.GetBot()
 	if err != nil {
 		logrus.WithError(err).Error("Error get bot name")
 	}
 
-	r := newRobot(c, cacheClient, v.Login)
+	ooo, err := newOOOStore(o.oooFile)
+	if err != nil {
+		logrus.WithError(err).Fatal("load the absences")
+	}
+
+	queue, err := newMergeQueue(o.queueFile)
+	if err != nil {
+		logrus.WithError(err).Fatal("load the merge queues")
+	}
+
+	affiliations, err := loadAffiliations(o.affiliation)
+	if err != nil {
+		logrus.WithError(err).Fatal("load the affiliations")
+	}
+
+	history := newCommitHistory(c, commitHistoryTTL)
+
+	// The framework only delivers the config with the events, so load it
+	// for the stale checker and admin api which run without events.
+	cfg, err := loadConfigFile(o.service.ConfigFile)
+	if err != nil {
+		logrus.WithError(err).Fatal("load the config")
+	}
+
+	if err := affiliations.check(cfg); err != nil {
+		logrus.WithError(err).Fatal("Invalid config")
+	}
+
+	if err := o.smtp.check(cfg); err != nil {
+		logrus.WithError(err).Fatal("Invalid config")
+	}
+
+	adminToken := func() []byte {
+		return bytes.TrimSpace(secretAgent.GetSecret(o.adminTokenPath))
+	}
+
+	auditStore, err := o.audit.newStore(secretAgent, false)
+	if err != nil {
+		logrus.WithError(err).Fatal("init audit store")
+	}
+
+	if auditStore != nil {
+		defer auditStore.Close()
+
+		if o.audit.port > 0 {
+			go serveAudit(o.audit.port, auditStore, adminToken)
+		}
+	}
+
+	publisher, err := o.mq.newPublisher()
+	if err != nil {
+		logrus.WithError(err).Fatal("init the publisher of message queue")
+	}
+
+	if publisher != nil {
+		defer publisher.Close()
+	}
+
+	r := newRobot(
+		c, cacheClient, history, ooo, queue, auditStore, affiliations,
+		o.smtp.newMailer(secretAgent), publisher, o.mq.topic,
+		o.retryAttempts, v.Login,
+	)
+
+	if cfg != nil {
+		r.setConfig(cfg)
+		r.resumeMergeQueues(logrus.WithField("component", "merge-queue"))
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	go r.runStaleChecker(o.staleCheckInterval, stop)
+
+	if o.adminPort > 0 {
+		go serveAdmin(o.adminPort, r, adminToken)
+	}
 
 	framework.Run(r, o.service)
 }
+
+// serveAudit serves the audit trail and report which are authenticated
+// by the same token as the admin api.
+func serveAudit(port int, s audit.Store, token func() []byte) {
+	mux := http.NewServeMux()
+	mux.Handle("/audit", audit.NewHandler(s))
+	mux.Handle("/report", report.NewHandler(s, reportOptions()))
+
+	if err := http.ListenAndServe(fmt.Sprintf(":%d", port), authenticate(mux, token)); err != nil {
+		logrus.WithError(err).Error("serve the query of audit trail and report")
+	}
+}
+
+func serveAdmin(port int, bot *robot, token func() []byte) {
+	if err := http.ListenAndServe(fmt.Sprintf(":%d", port), newAdminHandler(bot, token)); err != nil {
+		logrus.WithError(err).Error("serve the admin api")
+	}
+}
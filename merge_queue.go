@@ -0,0 +1,591 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opensourceways/community-robot-lib/giteeclient"
+	sdk "github.com/opensourceways/go-gitee/gitee"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	cmdQueue   = "QUEUE"
+	cmdDequeue = "DEQUEUE"
+
+	mergeQueueTitle = "### Merge Queue"
+)
+
+type mergeQueueConfig struct {
+	// Method is the merge method, merge, squash or rebase. The default is merge.
+	Method string `json:"method,omitempty"`
+
+	// RetestCommand is the comment to trigger the CI again when the PR gets
+	// to the head of queue, so that it is tested against the latest target
+	// branch. It is required unless there is no CI.
+	RetestCommand string `json:"retest_command,omitempty"`
+
+	// CITimeoutMinutes is the minutes to wait for the CI to pass before the
+	// PR is removed from the queue. The default is 120.
+	CITimeoutMinutes int `json:"ci_timeout_minutes,omitempty"`
+}
+
+func (c *mergeQueueConfig) setDefault() {
+	if c == nil {
+		return
+	}
+
+	if c.Method == "" {
+		c.Method = mergeMethodMerge
+	}
+
+	if c.CITimeoutMinutes <= 0 {
+		c.CITimeoutMinutes = 120
+	}
+}
+
+func (c *mergeQueueConfig) validate(noCI bool) error {
+	if c == nil {
+		return nil
+	}
+
+	if !noCI && c.RetestCommand == "" {
+		return fmt.Errorf("missing retest_command of merge_queue")
+	}
+
+	return (&autoMergeConfig{Method: c.Method}).validate()
+}
+
+// mergeQueue keeps the PRs which passed review in order for each target
+// branch. Only the head of queue is tested and merged at a time. The queues
+// are saved to a local file if the path is set, so that they survive the
+// restart of robot.
+type mergeQueue struct {
+	lock sync.Mutex
+	path string
+
+	// queues is the numbers of PR in order for each target branch.
+	queues map[string][]int32
+
+	// queueOf is the target branch which the PR is queued for.
+	queueOf map[string]string
+
+	// held is the PRs removed by the /dequeue command, which will not
+	// be queued again automatically.
+	held sets.String
+
+	// testing is the PRs which are being tested or merged at the head.
+	testing map[string]testingItem
+}
+
+type testingItem struct {
+	start time.Time
+	timer *time.Timer
+}
+
+// savedMergeQueue is the part of mergeQueue which is saved to file.
+type savedMergeQueue struct {
+	Queues map[string][]int32 `json:"queues"`
+	Held   []string           `json:"held,omitempty"`
+}
+
+func newMergeQueue(path string) (*mergeQueue, error) {
+	q := &mergeQueue{
+		path:    path,
+		queues:  map[string][]int32{},
+		queueOf: map[string]string{},
+		held:    sets.NewString(),
+		testing: map[string]testingItem{},
+	}
+	if path == "" {
+		return q, nil
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return q, nil
+		}
+		return nil, err
+	}
+
+	if len(b) == 0 {
+		return q, nil
+	}
+
+	var v savedMergeQueue
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+
+	for qk, numbers := range v.Queues {
+		if len(numbers) == 0 {
+			continue
+		}
+
+		q.queues[qk] = numbers
+
+		org, repo := splitQueueKey(qk)
+		for _, n := range numbers {
+			q.queueOf[fmt.Sprintf("%s/%s/%d", org, repo, n)] = qk
+		}
+	}
+
+	q.held.Insert(v.Held...)
+
+	return q, nil
+}
+
+func (q *mergeQueue) save() {
+	if q.path == "" {
+		return
+	}
+
+	b, err := json.Marshal(savedMergeQueue{Queues: q.queues, Held: q.held.List()})
+	if err == nil {
+		err = ioutil.WriteFile(q.path, b, 0644)
+	}
+
+	if err != nil {
+		logrus.WithError(err).Error("save the merge queue")
+	}
+}
+
+func queueKey(org, repo, branch string) string {
+	return fmt.Sprintf("%s/%s/%s", org, repo, branch)
+}
+
+func splitQueueKey(qk string) (string, string) {
+	v := strings.SplitN(qk, "/", 3)
+	return v[0], v[1]
+}
+
+// queueKeys returns the keys of all the queues which are not empty.
+func (q *mergeQueue) queueKeys() []string {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	r := make([]string, 0, len(q.queues))
+	for qk := range q.queues {
+		r = append(r, qk)
+	}
+
+	return r
+}
+
+// add appends the PR to the queue and returns its position which starts from 1.
+func (q *mergeQueue) add(pr iPRInfo) (pos int, isNew bool) {
+	org, repo := pr.getOrgAndRepo()
+	qk := queueKey(org, repo, pr.getTargetBranch())
+	key := prKey(pr)
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.queueOf[key] == qk {
+		return q.position(qk, pr.getNumber()), false
+	}
+
+	q.queues[qk] = append(q.queues[qk], pr.getNumber())
+	q.queueOf[key] = qk
+	q.save()
+
+	return len(q.queues[qk]), true
+}
+
+// remove removes the PR from the queue and returns the key of that queue.
+func (q *mergeQueue) remove(pr iPRInfo) (string, bool) {
+	key := prKey(pr)
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	qk, ok := q.queueOf[key]
+	if !ok {
+		return "", false
+	}
+
+	v := q.queues[qk]
+	for i, n := range v {
+		if n == pr.getNumber() {
+			q.queues[qk] = append(v[:i:i], v[i+1:]...)
+			break
+		}
+	}
+
+	if len(q.queues[qk]) == 0 {
+		delete(q.queues, qk)
+	}
+
+	delete(q.queueOf, key)
+
+	if t, ok := q.testing[key]; ok {
+		t.timer.Stop()
+		delete(q.testing, key)
+	}
+
+	q.save()
+
+	return qk, true
+}
+
+func (q *mergeQueue) position(qk string, number int32) int {
+	for i, n := range q.queues[qk] {
+		if n == number {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func (q *mergeQueue) members(qk string) []int32 {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return append([]int32{}, q.queues[qk]...)
+}
+
+func (q *mergeQueue) hold(pr iPRInfo, b bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if b {
+		q.held.Insert(prKey(pr))
+	} else {
+		q.held.Delete(prKey(pr))
+	}
+
+	q.save()
+}
+
+func (q *mergeQueue) isHeld(pr iPRInfo) bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return q.held.Has(prKey(pr))
+}
+
+// startTesting marks the head PR as being tested. It returns false if it is being tested.
+func (q *mergeQueue) startTesting(key string, timeout time.Duration, onTimeout func()) bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if _, ok := q.testing[key]; ok {
+		return false
+	}
+
+	q.testing[key] = testingItem{
+		start: time.Now(),
+		timer: time.AfterFunc(timeout, onTimeout),
+	}
+
+	return true
+}
+
+func (q *mergeQueue) stopTesting(key string) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if t, ok := q.testing[key]; ok {
+		t.timer.Stop()
+		delete(q.testing, key)
+	}
+}
+
+// testingSince returns the time when the PR started being tested.
+func (q *mergeQueue) testingSince(key string) (time.Time, bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	t, ok := q.testing[key]
+	return t.start, ok
+}
+
+// queueUpdater keeps the PR in the merge queue according to its review
+// state. All its methods can be called on nil which means the merge queue
+// is disabled.
+type queueUpdater struct {
+	bot *robot
+	pr  iPRInfo
+	cfg *botConfig
+	log *logrus.Entry
+}
+
+func (bot *robot) newQueueUpdater(pr iPRInfo, cfg *botConfig, log *logrus.Entry) *queueUpdater {
+	if cfg.MergeQueue == nil {
+		return nil
+	}
+
+	return &queueUpdater{bot: bot, pr: pr, cfg: cfg, log: log}
+}
+
+// update queues the PR which passes review, or removes the one which
+// doesn't. It returns the tips of queue position for the Review Guide.
+func (u *queueUpdater) update(pass bool) string {
+	if u == nil {
+		return ""
+	}
+
+	q := u.bot.mergeQueue
+
+	if !pass || q.isHeld(u.pr) {
+		if qk, ok := q.remove(u.pr); ok {
+			go u.bot.onQueueChanged(qk, u.cfg, u.log)
+		}
+
+		return ""
+	}
+
+	org, repo := u.pr.getOrgAndRepo()
+
+	pos, isNew := q.add(u.pr)
+	if isNew {
+		go u.bot.onQueueChanged(queueKey(org, repo, u.pr.getTargetBranch()), u.cfg, u.log)
+	} else if pos == 1 {
+		// The head may have stopped testing because its labels were not
+		// ready, see mergeQueueHead. It does nothing if it is being tested.
+		go func() {
+			if err := u.bot.testQueueHead(org, repo, u.pr.getNumber(), u.cfg, u.log); err != nil {
+				u.log.WithError(err).Error("test the head of merge queue")
+			}
+		}()
+	}
+
+	return fmt.Sprintf(
+		"It is **No.%d** in the merge queue of branch **%s**, and will be merged automatically.",
+		pos, u.pr.getTargetBranch(),
+	)
+}
+
+// onQueueChanged refreshes the Review Guides of the PRs in the queue
+// and starts testing the head of queue.
+func (bot *robot) onQueueChanged(qk string, cfg *botConfig, log *logrus.Entry) {
+	org, repo := splitQueueKey(qk)
+
+	numbers := bot.mergeQueue.members(qk)
+	for _, n := range numbers {
+		err := bot.withPRLock(fmt.Sprintf("%s/%s/%d", org, repo, n), func() error {
+			return bot.recheckPR(org, repo, n, cfg, log)
+		})
+		if err != nil {
+			log.WithError(err).Errorf("refresh the review state of %s/%s/%d", org, repo, n)
+		}
+	}
+
+	if len(numbers) > 0 {
+		if err := bot.testQueueHead(org, repo, numbers[0], cfg, log); err != nil {
+			log.WithError(err).Errorf("test the head of merge queue %s", qk)
+		}
+	}
+}
+
+// testQueueHead triggers the CI of head PR against the latest target branch.
+// It will be merged when the CI passes, see onCIPassed.
+func (bot *robot) testQueueHead(org, repo string, number int32, cfg *botConfig, log *logrus.Entry) error {
+	pr, err := bot.getPRInfo(org, repo, number)
+	if err != nil {
+		return err
+	}
+
+	key := prKey(pr)
+	timeout := time.Duration(cfg.MergeQueue.CITimeoutMinutes) * time.Minute
+
+	// It is marked as being tested even if there is no CI, so that it is
+	// not merged twice by the concurrent changes of queue.
+	started := bot.mergeQueue.startTesting(key, timeout, func() {
+		bot.dropFromQueue(
+			pr, cfg, log,
+			fmt.Sprintf("The CI didn't pass in %d minutes.", cfg.MergeQueue.CITimeoutMinutes),
+		)
+	})
+	if !started {
+		return nil
+	}
+
+	if cfg.CI.NoCI {
+		err := bot.mergeQueueHead(pr, time.Time{}, cfg, log)
+		if err != nil {
+			// try again at the next change of queue
+			bot.mergeQueue.stopTesting(key)
+		}
+
+		return err
+	}
+
+	return bot.client.CreatePRComment(org, repo, number, cfg.MergeQueue.RetestCommand)
+}
+
+// onCIPassed merges the head of queue if it is being tested.
+func (bot *robot) onCIPassed(pr iPRInfo, cfg *botConfig, log *logrus.Entry) error {
+	if cfg.MergeQueue == nil {
+		return nil
+	}
+
+	start, ok := bot.mergeQueue.testingSince(prKey(pr))
+	if !ok {
+		return nil
+	}
+
+	org, repo := pr.getOrgAndRepo()
+	v, err := bot.getPRInfo(org, repo, pr.getNumber())
+	if err != nil {
+		return err
+	}
+
+	return bot.mergeQueueHead(v, start, cfg, log)
+}
+
+// mergeQueueHead merges the head of queue whose CI passed after it
+// started being tested, which means it is tested against the latest
+// target branch.
+func (bot *robot) mergeQueueHead(pr prInfoOnAPI, testStart time.Time, cfg *botConfig, log *logrus.Entry) error {
+	if !pr.isOpen() {
+		bot.dropFromQueue(pr, cfg, log, "")
+		return nil
+	}
+
+	if !pr.hasLabel(labelLGTM) || !pr.hasLabel(labelApproved) {
+		// The labels may not have been added yet. It will be tested again
+		// when its review state is updated, see queueUpdater.update.
+		bot.mergeQueue.stopTesting(prKey(pr))
+		log.Infof("skip merging %s which has not got the labels of review", prKey(pr))
+		return nil
+	}
+
+	ciPassed, err := bot.client.isCIPassedOnHead(pr, cfg.CI, testStart)
+	if err != nil {
+		return err
+	}
+
+	if !ciPassed && !cfg.CI.NoCI {
+		// It is the result of CI which was triggered before testing.
+		log.Infof("skip merging %s whose CI has not passed since %s", prKey(pr), testStart)
+		return nil
+	}
+
+	if reasons := unmergeableReasons(pr, cfg, ciPassed); len(reasons) > 0 {
+		bot.dropFromQueue(pr, cfg, log, "It can't be merged because:\n- "+strings.Join(reasons, "\n- "))
+		return nil
+	}
+
+	org, repo := pr.getOrgAndRepo()
+	err = bot.client.MergePR(org, repo, pr.getNumber(), sdk.PullRequestMergePutParam{
+		MergeMethod: cfg.MergeQueue.Method,
+	})
+	if err != nil {
+		bot.dropFromQueue(pr, cfg, log, fmt.Sprintf("It failed to merge, err: %s", err.Error()))
+		return err
+	}
+
+	bot.dropFromQueue(pr, cfg, log, "")
+
+	return nil
+}
+
+// dropFromQueue removes the PR from the queue and comments the reason if it is not empty.
+func (bot *robot) dropFromQueue(pr iPRInfo, cfg *botConfig, log *logrus.Entry, reason string) {
+	qk, ok := bot.mergeQueue.remove(pr)
+	if !ok {
+		return
+	}
+
+	if reason != "" {
+		bot.mergeQueue.hold(pr, true)
+
+		org, repo := pr.getOrgAndRepo()
+		s := fmt.Sprintf(
+			"%s\n\nIt is removed from the merge queue. %s\nPlease comment `/queue` to queue it again after fixing it.",
+			mergeQueueTitle, reason,
+		)
+		if err := bot.client.CreatePRComment(org, repo, pr.getNumber(), s); err != nil {
+			log.WithError(err).Error("comment the removal from merge queue")
+		}
+	}
+
+	go bot.onQueueChanged(qk, cfg, log)
+}
+
+// resumeMergeQueues tests the heads of queues which are loaded from file
+// after the robot restarts.
+func (bot *robot) resumeMergeQueues(log *logrus.Entry) {
+	cfg := bot.currentConfig()
+	if cfg == nil {
+		return
+	}
+
+	for _, qk := range bot.mergeQueue.queueKeys() {
+		org, repo := splitQueueKey(qk)
+
+		if bc := cfg.configFor(org, repo); bc != nil && bc.MergeQueue != nil {
+			go bot.onQueueChanged(qk, bc, log)
+		}
+	}
+}
+
+// handleQueueComment handles the /queue and /dequeue commands which can be
+// commented by the PR author or the approvers of PR.
+func (bot *robot) handleQueueComment(e *noteEventInfo, cfg *botConfig, log *logrus.Entry) error {
+	if cfg.MergeQueue == nil {
+		return nil
+	}
+
+	prInfo := prInfoOnNoteEvent{e.NoteEvent}
+	org, repo := prInfo.getOrgAndRepo()
+
+	reply := func(s string) error {
+		return bot.client.CreatePRComment(
+			org, repo, prInfo.getNumber(),
+			giteeclient.GenResponseWithReference(e.NoteEvent, s),
+		)
+	}
+
+	if !e.isCommentedByPRAuthor() {
+		owner, err := bot.genRepoOwner(org, repo, prInfo.getTargetBranch(), cfg)
+		if err != nil {
+			return err
+		}
+
+		pr, err := bot.genPullRequest(prInfo, nil, owner)
+		if err != nil {
+			return err
+		}
+
+		if !pr.isApprover(e.normalizedCommenter()) {
+			return reply("Only the author or approvers of this PR can change the merge queue.")
+		}
+	}
+
+	if s := bot.mergeQueue.applyCommand(prInfo, e.cmds.Has(cmdDequeue)); s != "" {
+		return reply(s)
+	}
+
+	// recompute the review state to update the queue and Review Guide
+	return bot.recheckPR(org, repo, prInfo.getNumber(), cfg, log)
+}
+
+// applyCommand holds the PR out of queue by /dequeue, or releases it by
+// /queue. It returns the reply if the command can't be applied.
+func (q *mergeQueue) applyCommand(pr iPRInfo, dequeue bool) string {
+	if dequeue {
+		q.hold(pr, true)
+		return ""
+	}
+
+	if !pr.hasLabel(labelLGTM) || !pr.hasLabel(labelApproved) {
+		return "It can be queued only after it passes review."
+	}
+
+	q.hold(pr, false)
+
+	return ""
+}
+
+func (n *noteEventInfo) hasQueueCmd() bool {
+	return n.cmds.Has(cmdQueue) || n.cmds.Has(cmdDequeue)
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	sdk "github.com/opensourceways/go-gitee/gitee"
+	"github.com/sirupsen/logrus"
+)
+
+// fakeQueueClient records the merges and comments of the merge queue.
+// The PRs can't be fetched, so the rechecks started by the changes of
+// queue fail without touching the queue.
+type fakeQueueClient struct {
+	iClient
+
+	lock     sync.Mutex
+	merged   []int32
+	comments []string
+}
+
+func (c *fakeQueueClient) GetGiteePullRequest(org, repo string, number int32) (sdk.PullRequest, error) {
+	return sdk.PullRequest{}, errors.New("not found")
+}
+
+func (c *fakeQueueClient) MergePR(owner, repo string, number int32, opt sdk.PullRequestMergePutParam) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.merged = append(c.merged, number)
+	return nil
+}
+
+func (c *fakeQueueClient) CreatePRComment(owner, repo string, number int32, comment string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.comments = append(c.comments, comment)
+	return nil
+}
+
+func (c *fakeQueueClient) GetPRCommit(org, repo, SHA string) (sdk.RepoCommit, error) {
+	return sdk.RepoCommit{
+		Commit: &sdk.GitCommit{Committer: &sdk.GitUser{Date: time.Now().Add(-time.Hour)}},
+	}, nil
+}
+
+func (c *fakeQueueClient) ListPRComments(org, repo string, number int32) ([]sdk.PullRequestComments, error) {
+	return nil, nil
+}
+
+func newTestQueueBot(t *testing.T) (*robot, *fakeQueueClient) {
+	q, err := newMergeQueue(filepath.Join(t.TempDir(), "queue.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cli := &fakeQueueClient{}
+
+	return &robot{client: ghclient{cli}, mergeQueue: q, prLocks: newPRLocks()}, cli
+}
+
+func newTestQueueConfig(noCI bool) *botConfig {
+	cfg := &botConfig{}
+	cfg.CI.NoCI = noCI
+	cfg.MergeQueue = &mergeQueueConfig{RetestCommand: "/retest"}
+	cfg.MergeQueue.setDefault()
+
+	return cfg
+}
+
+func newTestQueuePR(number int32, mergeable bool, labels ...string) prInfoOnAPI {
+	pr := &sdk.PullRequest{Number: number, State: "open", Mergeable: mergeable}
+	for _, l := range labels {
+		pr.Labels = append(pr.Labels, sdk.Label{Name: l})
+	}
+
+	return newPRInfoOnAPI("org", "repo", pr)
+}
+
+func TestMergeQueueOrder(t *testing.T) {
+	bot, _ := newTestQueueBot(t)
+	q := bot.mergeQueue
+
+	for i, n := range []int32{3, 1, 2} {
+		pos, isNew := q.add(newTestQueuePR(n, true))
+		if pos != i+1 || !isNew {
+			t.Errorf("add %d: expect position %d of new one, but got %d, %v", n, i+1, pos, isNew)
+		}
+	}
+
+	if pos, isNew := q.add(newTestQueuePR(1, true)); pos != 2 || isNew {
+		t.Errorf("add again: expect position 2 of old one, but got %d, %v", pos, isNew)
+	}
+
+	qk, ok := q.remove(newTestQueuePR(3, true))
+	if !ok || qk != queueKey("org", "repo", "") {
+		t.Fatalf("remove: got %s, %v", qk, ok)
+	}
+
+	if v := q.members(qk); len(v) != 2 || v[0] != 1 || v[1] != 2 {
+		t.Errorf("expect [1 2] after removing the head, but got %v", v)
+	}
+
+	loaded, err := newMergeQueue(q.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v := loaded.members(qk); len(v) != 2 || v[0] != 1 || v[1] != 2 {
+		t.Errorf("expect [1 2] loaded from file, but got %v", v)
+	}
+}
+
+func TestMergeQueueCommands(t *testing.T) {
+	bot, _ := newTestQueueBot(t)
+	q := bot.mergeQueue
+	cfg := newTestQueueConfig(true)
+	log := logrus.WithField("test", t.Name())
+
+	passed := newTestQueuePR(1, true, labelLGTM, labelApproved)
+	u := bot.newQueueUpdater(passed, cfg, log)
+
+	if s := u.update(true); s == "" {
+		t.Error("expect the tips of queue position")
+	}
+
+	if s := q.applyCommand(passed, true); s != "" {
+		t.Errorf("/dequeue: expect no reply, but got %s", s)
+	}
+
+	if !q.isHeld(passed) {
+		t.Error("/dequeue: expect it to be held")
+	}
+
+	if s := u.update(true); s != "" {
+		t.Errorf("expect the held one not to be queued, but got %s", s)
+	}
+
+	if v := q.members(queueKey("org", "repo", "")); len(v) != 0 {
+		t.Errorf("expect the held one to be removed, but got %v", v)
+	}
+
+	if s := q.applyCommand(newTestQueuePR(1, true, labelLGTM), false); s == "" {
+		t.Error("/queue: expect a reply for the PR which has not passed review")
+	}
+
+	if !q.isHeld(passed) {
+		t.Error("/queue: expect it to be still held if it is rejected")
+	}
+
+	if s := q.applyCommand(passed, false); s != "" {
+		t.Errorf("/queue: expect no reply, but got %s", s)
+	}
+
+	if q.isHeld(passed) {
+		t.Error("/queue: expect it not to be held")
+	}
+
+	if pos, _ := q.add(passed); pos != 1 {
+		t.Errorf("expect it to be queued again at 1, but got %d", pos)
+	}
+}
+
+func TestMergeQueueHead(t *testing.T) {
+	cases := []struct {
+		name    string
+		noCI    bool
+		pr      prInfoOnAPI
+		merged  bool
+		queued  bool
+		held    bool
+		testing bool
+	}{
+		{
+			name:   "merge",
+			noCI:   true,
+			pr:     newTestQueuePR(1, true, labelLGTM, labelApproved),
+			merged: true,
+		},
+		{
+			name:   "labels are not ready",
+			noCI:   true,
+			pr:     newTestQueuePR(1, true, labelLGTM),
+			queued: true,
+		},
+		{
+			name: "unmergeable",
+			noCI: true,
+			pr:   newTestQueuePR(1, false, labelLGTM, labelApproved),
+			held: true,
+		},
+		{
+			name:    "CI has not passed since testing",
+			pr:      newTestQueuePR(1, true, labelLGTM, labelApproved),
+			queued:  true,
+			testing: true,
+		},
+	}
+
+	for _, c := range cases {
+		bot, cli := newTestQueueBot(t)
+		q := bot.mergeQueue
+		cfg := newTestQueueConfig(c.noCI)
+		log := logrus.WithField("test", c.name)
+
+		q.add(c.pr)
+		q.startTesting(prKey(c.pr), time.Hour, func() {})
+
+		start, _ := q.testingSince(prKey(c.pr))
+		if err := bot.mergeQueueHead(c.pr, start, cfg, log); err != nil {
+			t.Errorf("%s: %v", c.name, err)
+		}
+
+		cli.lock.Lock()
+		merged := len(cli.merged) > 0
+		cli.lock.Unlock()
+
+		if merged != c.merged {
+			t.Errorf("%s: expect merged=%v, but got %v", c.name, c.merged, merged)
+		}
+
+		if queued := len(q.members(queueKey("org", "repo", ""))) > 0; queued != c.queued {
+			t.Errorf("%s: expect queued=%v, but got %v", c.name, c.queued, queued)
+		}
+
+		if held := q.isHeld(c.pr); held != c.held {
+			t.Errorf("%s: expect held=%v, but got %v", c.name, c.held, held)
+		}
+
+		if _, ok := q.testingSince(prKey(c.pr)); ok != c.testing {
+			t.Errorf("%s: expect testing=%v, but got %v", c.name, c.testing, ok)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/opensourceways/community-robot-lib/utils"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -78,11 +79,42 @@ func parseCommentCommands(comment string) (r []string) {
 	return
 }
 
+// parseCommandReasons returns the argument of each command in the comment,
+// which is the reason of the negative review command.
+func parseCommandReasons(comment string) map[string]string {
+	r := map[string]string{}
+	for _, match := range commandRegex.FindAllStringSubmatch(comment, -1) {
+		cmd := strings.ToUpper(match[1])
+		if _, ok := r[cmd]; !ok || r[cmd] == "" {
+			r[cmd] = strings.TrimSpace(match[2])
+		}
+	}
+
+	return r
+}
+
+// isMissingReason checks whether the negative review command is commented
+// without a reason when the reason is required.
+func isMissingReason(cmd, comment string, cfg reviewConfig) bool {
+	if !cfg.RequireReasonToDisagree || !negativeCmds.Has(cmd) {
+		return false
+	}
+
+	return parseCommandReasons(comment)[cmd] == ""
+}
+
 type reviewSummary struct {
 	agreedApprovers    []string
 	agreedReviewers    []string
 	disagreedApprovers []string
 	disagreedReviewers []string
+
+	// reasons is the reasons of the disagreed approvers and reviewers.
+	reasons map[string]string
+
+	// ignoredVoters is the commenters whose review commands are ignored
+	// and the reasons. It is not counted in the summary.
+	ignoredVoters map[string]string
 }
 
 func (r reviewSummary) NumberOfAssentor() int {
@@ -104,9 +136,16 @@ func (r reviewSummary) IsEmpty() bool {
 	return true
 }
 
+// hasNoCommand checks whether there is no review command, including the
+// ignored ones whose tips should be shown too.
+func (r reviewSummary) hasNoCommand() bool {
+	return r.IsEmpty() && len(r.ignoredVoters) == 0
+}
+
 type reviewCommand struct {
 	author  string
 	command string
+	reason  string
 }
 
 func genReviewSummary(cmds []reviewCommand) reviewSummary {
@@ -114,7 +153,12 @@ func genReviewSummary(cmds []reviewCommand) reviewSummary {
 	agreedReviewers := sets.NewString()
 	disagreedApprovers := sets.NewString()
 	disagreedReviewers := sets.NewString()
+	reasons := map[string]string{}
 	for _, c := range cmds {
+		if negativeCmds.Has(c.command) && c.reason != "" {
+			reasons[c.author] = c.reason
+		}
+
 		switch c.command {
 		case cmdLGTM:
 			agreedReviewers.Insert(c.author)
@@ -132,6 +176,7 @@ func genReviewSummary(cmds []reviewCommand) reviewSummary {
 		agreedReviewers:    agreedReviewers.List(),
 		disagreedApprovers: disagreedApprovers.List(),
 		disagreedReviewers: disagreedReviewers.List(),
+		reasons:            reasons,
 	}
 }
 
@@ -190,21 +235,54 @@ type reviewResult struct {
 	isLGTM      bool
 	isLBTM      bool
 	needLGTMNum int
+
+	// unmetRule describes the approval requirements which are not
+	// satisfied, one for each line.
+	unmetRule string
+
+	// overruledRejecters is the approvers whose /reject is overruled
+	// by the rejection policy.
+	overruledRejecters []string
+
+	// waitUntil is the time after which the approved label can be added.
+	// It is set only when the PR is approved but the min review hours is not reached.
+	waitUntil time.Time
 }
 
 func genReviewResult(r reviewSummary, allFilesApproved func([]string, int) bool,
-	areAllFilesCommented func([]string, int) bool, cfg reviewConfig) reviewResult {
+	areAllFilesCommented func([]string, int) bool, unmetRule func([]string) string,
+	approvableAt time.Time, rejection rejectionPolicy, cfg reviewConfig) reviewResult {
 	rr := reviewResult{}
 
 	if len(r.disagreedApprovers) > 0 {
-		rr.isRejected = true
-		return rr
+		rejected, overruled := rejection.isRejected(r.disagreedApprovers, r.agreedApprovers)
+		if rejected {
+			rr.isRejected = true
+			return rr
+		}
+
+		rr.overruledRejecters = overruled
 	}
 
 	an := len(r.agreedApprovers)
 
+	shortfall := ""
 	if allFilesApproved(r.agreedApprovers, cfg.NumberOfApprovers) {
-		rr.isApproved = an >= cfg.TotalNumberOfApprovers
+		rr.isApproved = cfg.approvalWeight(r.agreedApprovers) >= cfg.TotalNumberOfApprovers
+		shortfall = cfg.weightShortfall(r.agreedApprovers)
+	}
+
+	if rr.unmetRule = unmetRule(r.agreedApprovers); rr.unmetRule != "" {
+		rr.isApproved = false
+	}
+
+	if shortfall != "" {
+		rr.unmetRule = joinNonEmpty(notificationLineSpliter, rr.unmetRule, shortfall)
+	}
+
+	if rr.isApproved && time.Now().Before(approvableAt) {
+		rr.isApproved = false
+		rr.waitUntil = approvableAt
 	}
 
 	rn := an + len(r.agreedReviewers)
@@ -250,4 +328,6 @@ type iPRInfo interface {
 	hasLabel(string) bool
 	getAuthor() string
 	getHeadSHA() string
+	getTitle() string
+	isDraft() bool
 }
@@ -0,0 +1,55 @@
+package mq
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+const kafkaTimeout = 10 * time.Second
+
+// kafkaPublisher produces the messages to the kafka brokers. The messages
+// with same key are written to the same partition to keep them in order.
+type kafkaPublisher struct {
+	w *kafka.Writer
+}
+
+func newKafkaPublisher(address string) (*kafkaPublisher, error) {
+	var brokers []string
+	for _, item := range strings.Split(address, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			brokers = append(brokers, item)
+		}
+	}
+
+	if len(brokers) == 0 {
+		return nil, fmt.Errorf("invalid address of kafka: %s", address)
+	}
+
+	return &kafkaPublisher{
+		w: &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+			WriteTimeout: kafkaTimeout,
+		},
+	}, nil
+}
+
+func (p *kafkaPublisher) Publish(topic, key string, value []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), kafkaTimeout)
+	defer cancel()
+
+	return p.w.WriteMessages(ctx, kafka.Message{
+		Topic: topic,
+		Key:   []byte(key),
+		Value: value,
+	})
+}
+
+func (p *kafkaPublisher) Close() error {
+	return p.w.Close()
+}
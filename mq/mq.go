@@ -0,0 +1,77 @@
+// Package mq publishes the messages to a message queue, so that the other
+// robots can subscribe to the review state of PR instead of polling.
+package mq
+
+import (
+	"fmt"
+	"sync"
+)
+
+const (
+	// KindKafka publishes to kafka. The address is the brokers separated
+	// by comma, such as kafka-0:9092,kafka-1:9092.
+	KindKafka = "kafka"
+
+	// KindNATS publishes to nats. The address is the servers separated by
+	// comma, such as nats://nats:4222. tls:// is for the secure connection.
+	KindNATS = "nats"
+
+	// KindMemory keeps the messages in process. It is used in tests.
+	KindMemory = "memory"
+)
+
+// Publisher publishes the value to the topic. The key is used by the
+// queue which supports partition to keep the order of messages with same key.
+type Publisher interface {
+	Publish(topic, key string, value []byte) error
+	Close() error
+}
+
+func NewPublisher(kind, address string) (Publisher, error) {
+	switch kind {
+	case KindKafka:
+		return newKafkaPublisher(address)
+
+	case KindNATS:
+		return newNATSPublisher(address)
+
+	case KindMemory:
+		return new(Memory), nil
+	}
+
+	return nil, fmt.Errorf("unknown kind of message queue: %s", kind)
+}
+
+// Message is the message kept by Memory.
+type Message struct {
+	Topic string
+	Key   string
+	Value []byte
+}
+
+// Memory is the in-process publisher which keeps all the messages.
+type Memory struct {
+	lock     sync.Mutex
+	messages []Message
+}
+
+func (m *Memory) Publish(topic, key string, value []byte) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.messages = append(m.messages, Message{Topic: topic, Key: key, Value: value})
+
+	return nil
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
+
+// Messages returns the messages published so far.
+func (m *Memory) Messages() []Message {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return append([]Message(nil), m.messages...)
+}
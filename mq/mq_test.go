@@ -0,0 +1,36 @@
+package mq
+
+import (
+	"testing"
+)
+
+func TestMemory(t *testing.T) {
+	p, err := NewPublisher(KindMemory, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.Publish("t", "k", []byte(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	v := p.(*Memory).Messages()
+	if len(v) != 1 || v[0].Topic != "t" || v[0].Key != "k" || string(v[0].Value) != "{}" {
+		t.Errorf("unexpected messages: %v", v)
+	}
+}
+
+func TestKafkaAddress(t *testing.T) {
+	if _, err := NewPublisher(KindKafka, " , "); err == nil {
+		t.Error("expect an error for the address without broker")
+	}
+
+	p, err := newKafkaPublisher("kafka-0:9092, kafka-1:9092")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s := p.w.Addr.String(); s != "kafka-0:9092,kafka-1:9092" {
+		t.Errorf("unexpected brokers: %s", s)
+	}
+}
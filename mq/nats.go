@@ -0,0 +1,43 @@
+package mq
+
+import (
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+const natsTimeout = 10 * time.Second
+
+// natsPublisher publishes to the nats servers. The connection is rebuilt
+// by the client if it is broken, and tls is used for the tls:// servers.
+type natsPublisher struct {
+	conn *nats.Conn
+}
+
+func newNATSPublisher(address string) (*natsPublisher, error) {
+	conn, err := nats.Connect(
+		address,
+		nats.Name("robot-gitee-review-trigger"),
+		nats.Timeout(natsTimeout),
+		nats.MaxReconnects(-1),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &natsPublisher{conn: conn}, nil
+}
+
+// Publish waits until the server has processed the message. The key is
+// not used, since the messages of a subject are kept in order by nats.
+func (p *natsPublisher) Publish(topic, key string, value []byte) error {
+	if err := p.conn.Publish(topic, value); err != nil {
+		return err
+	}
+
+	return p.conn.FlushTimeout(natsTimeout)
+}
+
+func (p *natsPublisher) Close() error {
+	return p.conn.Drain()
+}
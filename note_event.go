@@ -34,6 +34,21 @@ func (bot *robot) processNoteEvent(e *sdk.NoteEvent, cfg *botConfig, log *logrus
 			mr.AddError(err)
 		}
 
+		if info.cmds.Has(cmdCC) {
+			err := bot.handleCCComment(info, cfg, log)
+			mr.AddError(err)
+		}
+
+		if info.hasQueueCmd() {
+			err := bot.handleQueueComment(info, cfg, log)
+			mr.AddError(err)
+		}
+
+		if info.hasOOOCmd() {
+			err := bot.handleOOOComment(info, log)
+			mr.AddError(err)
+		}
+
 		return mr.Err()
 	}
 
@@ -41,48 +56,21 @@ func (bot *robot) processNoteEvent(e *sdk.NoteEvent, cfg *botConfig, log *logrus
 }
 
 func (bot *robot) handleReviewComment(e *noteEventInfo, cfg *botConfig, log *logrus.Entry) error {
-	org, repo := e.GetOrgRepo()
-	owner, err := bot.genRepoOwner(org, repo, e.GetPRBaseRef())
-	if err != nil {
-		return err
-	}
-
 	prInfo := prInfoOnNoteEvent{e.NoteEvent}
-	pr, err := bot.genPullRequest(prInfo, getAssignees(e.GetPullRequest()), owner)
+
+	ctx, err := bot.genReviewContext(prInfo, getAssignees(e.GetPullRequest()), cfg)
 	if err != nil {
 		return err
 	}
 
-	stats := &reviewStats{
-		pr:        &pr,
-		cfg:       cfg.Review,
-		reviewers: owner.AllReviewers(),
-	}
-
-	cmd, validReview := bot.isValidReview(cfg, stats, e, log)
+	cmd, validReview := bot.isValidReview(cfg, ctx.stats, e, log)
 	if !validReview {
 		return nil
 	}
 
-	info, err := bot.getReviewInfo(prInfo)
-	if err != nil {
-		return err
-	}
+	_, _, err = bot.applyReview(ctx, cfg, e.GetCommenter(), cmd, log)
 
-	canReview := cfg.CI.NoCI || stats.pr.info.hasLabel(cfg.CI.LabelForCIPassed)
-	pa := PostAction{
-		c:                bot.client,
-		cfg:              cfg,
-		owner:            owner,
-		log:              log,
-		pr:               &pr,
-		isStartingReview: canReview,
-	}
-
-	oldTips := info.reviewGuides(bot.botName)
-	rs, rr := info.doStats(stats, bot.botName)
-
-	return pa.do(oldTips, cmd, rs, rr, bot.botName)
+	return err
 }
 
 func (bot *robot) isValidReview(
@@ -96,9 +84,35 @@ func (bot *robot) isValidReview(
 		cmd, invalidCmd = e.checkReviewCmd(stats.genCheckCmdFunc())
 	}
 
+	// reply it first, since the valid command may be ignored below
+	if invalidCmd != "" {
+		s := fmt.Sprintf(
+			"You can't comment `/%s`. Please see the [*Command Usage*](%s) to get detail.",
+			strings.ToLower(invalidCmd),
+			cfg.commandsEndpoint,
+		)
+
+		bot.replyUsage(stats.pr.info, e, s)
+	}
+
 	commenter := e.normalizedCommenter()
 	validReview := cmd != "" && stats.isReviewer(commenter)
 
+	if reason := stats.commenters.ignoredReason(commenter); validReview && reason != "" {
+		log.Infof("ignore the review command of %s, because it is %s", commenter, reason)
+
+		return cmd, false
+	}
+
+	if validReview && isMissingReason(cmd, e.GetComment().GetBody(), cfg.Review) {
+		bot.replyUsage(stats.pr.info, e, fmt.Sprintf(
+			"You should give a reason when commenting `/%s`, such as `/%s the design should be discussed first`. Please see the [*Command Usage*](%s) to get detail.",
+			strings.ToLower(cmd), strings.ToLower(cmd), cfg.commandsEndpoint,
+		))
+
+		return cmd, false
+	}
+
 	if !validReview {
 		log.Infof(
 			"It can't handle note event, because cmd(%s) is empty or commenter(%s) is not a reviewer. There are %d reviewers.",
@@ -106,24 +120,16 @@ func (bot *robot) isValidReview(
 		)
 	}
 
-	if invalidCmd != "" {
-
-		info := stats.pr.info
-		org, repo := info.getOrgAndRepo()
-
-		s := fmt.Sprintf(
-			"You can't comment `/%s`. Please see the [*Command Usage*](%s) to get detail.",
-			strings.ToLower(invalidCmd),
-			cfg.commandsEndpoint,
-		)
+	return cmd, validReview
+}
 
-		bot.client.CreatePRComment(
-			org, repo, info.getNumber(),
-			giteeclient.GenResponseWithReference(e.NoteEvent, s),
-		)
-	}
+func (bot *robot) replyUsage(info iPRInfo, e *noteEventInfo, s string) {
+	org, repo := info.getOrgAndRepo()
 
-	return cmd, validReview
+	bot.client.CreatePRComment(
+		org, repo, info.getNumber(),
+		giteeclient.GenResponseWithReference(e.NoteEvent, s),
+	)
 }
 
 type noteEventInfo struct {
@@ -143,6 +149,10 @@ func (n *noteEventInfo) hasCanReviewCmd() bool {
 	return n.cmds.Has(cmdCanReview)
 }
 
+func (n *noteEventInfo) hasOOOCmd() bool {
+	return n.cmds.Has(cmdOOO) || n.cmds.Has(cmdBack)
+}
+
 func (n *noteEventInfo) isCommentedByPRAuthor() bool {
 	return n.GetCommenter() == n.GetPRAuthor()
 }
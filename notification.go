@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/opensourceways/robot-gitee-review-trigger/notify"
+)
+
+type notificationConfig struct {
+	// Kind is the kind of sink: webhook, wecom, feishu, slack or email.
+	Kind string `json:"kind" required:"true"`
+
+	// URL is the address of webhook. It is required unless the kind is email.
+	URL string `json:"url,omitempty"`
+
+	// To is the recipients of email. It is required if the kind is email.
+	To []string `json:"to,omitempty"`
+
+	// Events is the events to send: ready_for_review, lgtm, approved,
+	// rejected, pass_review and stale. All of them are sent if it is empty.
+	Events []string `json:"events,omitempty"`
+
+	// Template is the text/template of message whose data has the fields
+	// of Kind, Org, Repo, Number, Title, Author, URL, Actor and Detail.
+	Template string `json:"template,omitempty"`
+}
+
+func (c *notificationConfig) validate() error {
+	for _, item := range c.Events {
+		if !notify.Events.Has(item) {
+			return fmt.Errorf("unknown event of notification: %s", item)
+		}
+	}
+
+	return c.options().Validate()
+}
+
+func (c *notificationConfig) options() notify.Options {
+	return notify.Options{
+		Kind:     c.Kind,
+		URL:      c.URL,
+		To:       c.To,
+		Template: c.Template,
+	}
+}
+
+func (c *notificationConfig) accept(event string) bool {
+	return len(c.Events) == 0 || sets.NewString(c.Events...).Has(event)
+}
+
+// notifier sends the review events of a PR to the sinks of its repo.
+// All its methods can be called on nil which means no sink is configured.
+type notifier struct {
+	sinks  []notificationConfig
+	mailer *notify.Mailer
+	event  notify.Event
+	log    *logrus.Entry
+
+	// readyKey is the key of ready_for_review event in the sent cache.
+	readyKey string
+	sent     *eventCache
+}
+
+func (bot *robot) newNotifier(pr iPRInfo, actor string, cfg *botConfig, log *logrus.Entry) *notifier {
+	if len(cfg.Notifications) == 0 {
+		return nil
+	}
+
+	org, repo := pr.getOrgAndRepo()
+
+	return &notifier{
+		sinks:    cfg.Notifications,
+		mailer:   bot.mailer,
+		log:      log,
+		readyKey: fmt.Sprintf("%s/%s/%d/%s", org, repo, pr.getNumber(), pr.getHeadSHA()),
+		sent:     bot.readyEvents,
+		event: notify.Event{
+			Org:    org,
+			Repo:   repo,
+			Number: pr.getNumber(),
+			Title:  pr.getTitle(),
+			Author: pr.getAuthor(),
+			URL:    fmt.Sprintf("https://gitee.com/%s/%s/pulls/%d", org, repo, pr.getNumber()),
+			Actor:  actor,
+		},
+	}
+}
+
+// notify sends the event in background, so the handling of PR is not
+// blocked by the sinks.
+func (n *notifier) notify(kind, detail string) {
+	if n == nil {
+		return
+	}
+
+	e := n.event
+	e.Kind = kind
+	e.Detail = detail
+	e.Time = time.Now()
+
+	go n.send(e)
+}
+
+// notifyReady sends the ready_for_review event only once for each head
+// commit, because the can-review label may be added by both readyToReview
+// and the PostAction for the same commit.
+func (n *notifier) notifyReady() {
+	if n == nil || !n.sent.begin(n.readyKey) {
+		return
+	}
+
+	n.sent.end(n.readyKey, true)
+
+	n.notify(notify.EventReadyForReview, "")
+}
+
+func (n *notifier) send(e notify.Event) {
+	for i := range n.sinks {
+		c := &n.sinks[i]
+		if !c.accept(e.Kind) {
+			continue
+		}
+
+		s, err := notify.NewSink(c.options(), n.mailer)
+		if err == nil {
+			err = s.Send(e)
+		}
+
+		if err != nil {
+			n.log.WithError(err).Errorf("send the %s event to %s", e.Kind, c.Kind)
+		}
+	}
+}
+
+// onReviewChanged sends the event of review transition from the state
+// before the labels are updated to the current one, so that the change
+// between the states with the same labels, such as from /lbtm to /reject,
+// is also sent. The keep is the review labels which should be on PR now.
+func (n *notifier) onReviewChanged(
+	hadLabel func(string) bool, wasRejected bool,
+	added, keep []string, rs *reviewSummary, rr *reviewResult,
+) {
+	if n == nil {
+		return
+	}
+
+	k, v := sets.NewString(keep...), sets.NewString(added...)
+	hasLabel := func(l string) bool {
+		return k.Has(l) && (hadLabel(l) || v.Has(l))
+	}
+
+	e := reviewEvent(hasLabel, rr.isRejected)
+	if e == "" || e == reviewEvent(hadLabel, wasRejected) {
+		return
+	}
+
+	switch e {
+	case notify.EventLGTM:
+		n.notify(e, joinVoters("It is reviewed by", rs.agreedReviewers, rs.agreedApprovers))
+
+	case notify.EventApproved:
+		n.notify(e, joinVoters("It is approved by", rs.agreedApprovers))
+
+	case notify.EventRejected:
+		n.notify(e, joinVoters("It is rejected by", rs.disagreedApprovers))
+
+	case notify.EventReadyForReview:
+		n.notifyReady()
+
+	default:
+		n.notify(e, "")
+	}
+}
+
+// reviewEvent returns the event of review state which is indicated by the
+// review labels. It is empty if the state has no event, such as requesting
+// change.
+func reviewEvent(hasLabel func(string) bool, isRejected bool) string {
+	switch {
+	case hasLabel(labelLGTM) && hasLabel(labelApproved):
+		return notify.EventPassReview
+
+	case hasLabel(labelRequestChange):
+		if isRejected {
+			return notify.EventRejected
+		}
+		return ""
+
+	case hasLabel(labelLGTM):
+		return notify.EventLGTM
+
+	case hasLabel(labelApproved):
+		return notify.EventApproved
+
+	case hasLabel(labelCanReview):
+		return notify.EventReadyForReview
+	}
+
+	return ""
+}
+
+func joinVoters(prefix string, voters ...[]string) string {
+	v := sets.NewString()
+	for _, item := range voters {
+		v.Insert(item...)
+	}
+
+	if v.Len() == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("%s %s.", prefix, strings.Join(v.List(), ", "))
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/opensourceways/robot-gitee-review-trigger/notify"
+)
+
+func TestReviewEvent(t *testing.T) {
+	cases := []struct {
+		labels     []string
+		isRejected bool
+		expect     string
+	}{
+		{[]string{labelCanReview}, false, notify.EventReadyForReview},
+		{[]string{labelLGTM}, false, notify.EventLGTM},
+		{[]string{labelApproved}, false, notify.EventApproved},
+		{[]string{labelLGTM, labelApproved}, false, notify.EventPassReview},
+		{[]string{labelRequestChange}, false, ""},
+		{[]string{labelRequestChange}, true, notify.EventRejected},
+		{nil, false, ""},
+	}
+
+	for _, c := range cases {
+		if e := reviewEvent(sets.NewString(c.labels...).Has, c.isRejected); e != c.expect {
+			t.Errorf("%v, rejected=%v: expect %q, but got %q", c.labels, c.isRejected, c.expect, e)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package notify
+
+import (
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"text/template"
+)
+
+// Mailer sends email by the smtp server.
+type Mailer struct {
+	// Addr is the address of smtp server, such as smtp.example.com:587.
+	Addr     string
+	From     string
+	Username string
+	Password string
+
+	// send is smtp.SendMail. It can be replaced in tests.
+	send func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+func (m *Mailer) SendMail(to []string, subject, body string) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		host, _, err := net.SplitHostPort(m.Addr)
+		if err != nil {
+			return err
+		}
+
+		auth = smtp.PlainAuth("", m.Username, m.Password, host)
+	}
+
+	// the subject may be non-ASCII and must not inject the headers
+	subject = strings.Join(strings.FieldsFunc(subject, func(r rune) bool {
+		return r == '\r' || r == '\n'
+	}), " ")
+
+	msg := fmt.Sprintf(
+		"From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		m.From, strings.Join(to, ", "), mime.QEncoding.Encode("utf-8", subject), body,
+	)
+
+	send := m.send
+	if send == nil {
+		send = smtp.SendMail
+	}
+
+	return send(m.Addr, auth, m.From, to, []byte(msg))
+}
+
+// emailSink sends the message by email whose subject is the first line of message.
+type emailSink struct {
+	mailer *Mailer
+	to     []string
+	tmpl   *template.Template
+}
+
+func (s *emailSink) Send(e Event) error {
+	msg, err := render(s.tmpl, e)
+	if err != nil {
+		return err
+	}
+
+	subject := strings.TrimSpace(strings.SplitN(msg, "\n", 2)[0])
+
+	return s.mailer.SendMail(s.to, subject, msg)
+}
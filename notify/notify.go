@@ -0,0 +1,156 @@
+// Package notify sends the review events of PR to the sinks outside
+// of gitee, such as a webhook, an IM group or email.
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	EventReadyForReview = "ready_for_review"
+	EventLGTM           = "lgtm"
+	EventApproved       = "approved"
+	EventRejected       = "rejected"
+	EventPassReview     = "pass_review"
+	EventStale          = "stale"
+
+	SinkWebhook = "webhook"
+	SinkWeCom   = "wecom"
+	SinkFeishu  = "feishu"
+	SinkSlack   = "slack"
+	SinkEmail   = "email"
+
+	// DefaultTemplate is the template of message if it is not set.
+	DefaultTemplate = `[{{.Org}}/{{.Repo}}] PR !{{.Number}} "{{.Title}}" of {{.Author}} {{describe .Kind}}.` +
+		`{{if .Detail}} {{.Detail}}{{end}}
+{{.URL}}`
+)
+
+var (
+	Events = sets.NewString(
+		EventReadyForReview, EventLGTM, EventApproved,
+		EventRejected, EventPassReview, EventStale,
+	)
+
+	descriptions = map[string]string{
+		EventReadyForReview: "is ready for review",
+		EventLGTM:           "got lgtm",
+		EventApproved:       "is approved",
+		EventRejected:       "is rejected",
+		EventPassReview:     "passed review",
+		EventStale:          "is stale",
+	}
+
+	funcs = template.FuncMap{
+		"describe": func(kind string) string {
+			if s, ok := descriptions[kind]; ok {
+				return s
+			}
+			return kind
+		},
+	}
+)
+
+// Event is a transition of review state of PR.
+type Event struct {
+	Kind   string    `json:"kind"`
+	Org    string    `json:"org"`
+	Repo   string    `json:"repo"`
+	Number int32     `json:"number"`
+	Title  string    `json:"title"`
+	Author string    `json:"author"`
+	URL    string    `json:"url"`
+	Actor  string    `json:"actor,omitempty"`
+	Detail string    `json:"detail,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// Sink sends the event to somewhere.
+type Sink interface {
+	Send(Event) error
+}
+
+// Options is the options of sink.
+type Options struct {
+	// Kind is the kind of sink: webhook, wecom, feishu, slack or email.
+	Kind string
+
+	// URL is the address of webhook. It is required unless the Kind is email.
+	URL string
+
+	// To is the recipients of email. It is required if the Kind is email.
+	To []string
+
+	// Template is the text/template of message with Event as its data.
+	// The DefaultTemplate is used if it is empty.
+	Template string
+}
+
+func (o Options) Validate() error {
+	switch o.Kind {
+	case SinkWebhook, SinkWeCom, SinkFeishu, SinkSlack:
+		if o.URL == "" {
+			return fmt.Errorf("missing url of %s", o.Kind)
+		}
+
+	case SinkEmail:
+		if len(o.To) == 0 {
+			return fmt.Errorf("missing recipients of email")
+		}
+
+	default:
+		return fmt.Errorf("unknown kind of sink: %s", o.Kind)
+	}
+
+	_, err := parseTemplate(o.Template)
+
+	return err
+}
+
+// NewSink creates the sink. The mailer is required only for the email.
+func NewSink(o Options, m *Mailer) (Sink, error) {
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+
+	t, err := parseTemplate(o.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	switch o.Kind {
+	case SinkWebhook:
+		return &webhookSink{url: o.URL, tmpl: t, client: newHTTPClient()}, nil
+
+	case SinkEmail:
+		if m == nil {
+			return nil, fmt.Errorf("the smtp server is not configured")
+		}
+
+		return &emailSink{mailer: m, to: o.To, tmpl: t}, nil
+	}
+
+	return &chatSink{kind: o.Kind, url: o.URL, tmpl: t, client: newHTTPClient()}, nil
+}
+
+func parseTemplate(s string) (*template.Template, error) {
+	if s == "" {
+		s = DefaultTemplate
+	}
+
+	return template.New("message").Funcs(funcs).Parse(s)
+}
+
+func render(t *template.Template, e Event) (string, error) {
+	b := new(bytes.Buffer)
+	if err := t.Execute(b, e); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,201 @@
+package notify
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"testing"
+)
+
+var testEvent = Event{
+	Kind:   EventApproved,
+	Org:    "o",
+	Repo:   "r",
+	Number: 1,
+	Title:  "fix bug",
+	Author: "alice",
+	URL:    "https://gitee.com/o/r/pulls/1",
+}
+
+// standIn records the body of requests and responds with resp.
+func standIn(t *testing.T, resp string, bodies *[]map[string]interface{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+
+		v := map[string]interface{}{}
+		if err := json.Unmarshal(b, &v); err != nil {
+			t.Errorf("invalid json: %s", b)
+		}
+		*bodies = append(*bodies, v)
+
+		w.Write([]byte(resp))
+	}))
+}
+
+func TestWebhook(t *testing.T) {
+	var bodies []map[string]interface{}
+	s := standIn(t, "", &bodies)
+	defer s.Close()
+
+	sink, err := NewSink(Options{Kind: SinkWebhook, URL: s.URL}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sink.Send(testEvent); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(bodies) != 1 {
+		t.Fatalf("expect 1 request, but got %d", len(bodies))
+	}
+
+	b := bodies[0]
+	if b["kind"] != EventApproved || b["org"] != "o" {
+		t.Errorf("unexpected payload: %v", b)
+	}
+
+	expect := "[o/r] PR !1 \"fix bug\" of alice is approved.\nhttps://gitee.com/o/r/pulls/1"
+	if b["message"] != expect {
+		t.Errorf("expect message %q, but got %q", expect, b["message"])
+	}
+}
+
+func TestChat(t *testing.T) {
+	cases := []struct {
+		kind string
+		resp string
+		text func(map[string]interface{}) interface{}
+		fail bool
+	}{
+		{
+			kind: SinkWeCom,
+			resp: `{"errcode":0,"errmsg":"ok"}`,
+			text: func(v map[string]interface{}) interface{} {
+				return v["text"].(map[string]interface{})["content"]
+			},
+		},
+		{
+			kind: SinkFeishu,
+			resp: `{"code":0,"msg":"success"}`,
+			text: func(v map[string]interface{}) interface{} {
+				return v["content"].(map[string]interface{})["text"]
+			},
+		},
+		{
+			kind: SinkSlack,
+			resp: "ok",
+			text: func(v map[string]interface{}) interface{} {
+				return v["text"]
+			},
+		},
+		{
+			kind: SinkWeCom,
+			resp: `{"errcode":93000,"errmsg":"invalid webhook url"}`,
+			fail: true,
+		},
+	}
+
+	for _, c := range cases {
+		var bodies []map[string]interface{}
+		s := standIn(t, c.resp, &bodies)
+
+		sink, err := NewSink(Options{Kind: c.kind, URL: s.URL, Template: "{{.Repo}} {{.Kind}}"}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = sink.Send(testEvent)
+		s.Close()
+
+		if c.fail {
+			if err == nil {
+				t.Errorf("%s: expect an error for response %s", c.kind, c.resp)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: %v", c.kind, err)
+			continue
+		}
+
+		if v := c.text(bodies[0]); v != "r approved" {
+			t.Errorf("%s: unexpected text: %v", c.kind, v)
+		}
+	}
+}
+
+func TestWebhookStatusError(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer s.Close()
+
+	sink, _ := NewSink(Options{Kind: SinkWebhook, URL: s.URL}, nil)
+	if err := sink.Send(testEvent); err == nil {
+		t.Error("expect an error for status 500")
+	}
+}
+
+func TestEmail(t *testing.T) {
+	var to []string
+	var msg string
+
+	m := &Mailer{
+		Addr: "localhost:25",
+		From: "robot@example.com",
+		send: func(addr string, a smtp.Auth, from string, rcpt []string, b []byte) error {
+			to = rcpt
+			msg = string(b)
+			return nil
+		},
+	}
+
+	if _, err := NewSink(Options{Kind: SinkEmail, To: []string{"a@example.com"}}, nil); err == nil {
+		t.Error("expect an error without mailer")
+	}
+
+	sink, err := NewSink(Options{Kind: SinkEmail, To: []string{"a@example.com"}}, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sink.Send(testEvent); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(to) != 1 || to[0] != "a@example.com" {
+		t.Errorf("unexpected recipients: %v", to)
+	}
+
+	if !strings.Contains(msg, "Subject: [o/r] PR !1 \"fix bug\" of alice is approved.\r\n") {
+		t.Errorf("unexpected mail: %s", msg)
+	}
+
+	if err := m.SendMail(to, "修复\r\nBcc: x@example.com", "body"); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(msg, "Subject: =?utf-8?q?=E4=BF=AE=E5=A4=8D_Bcc:_x@example.com?=\r\n") {
+		t.Errorf("unexpected subject: %s", msg)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cases := []Options{
+		{Kind: "unknown", URL: "http://x"},
+		{Kind: SinkWebhook},
+		{Kind: SinkEmail},
+		{Kind: SinkSlack, URL: "http://x", Template: "{{.Repo"},
+	}
+
+	for _, c := range cases {
+		if c.Validate() == nil {
+			t.Errorf("expect an error for %+v", c)
+		}
+	}
+}
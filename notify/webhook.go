@@ -0,0 +1,122 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"text/template"
+	"time"
+)
+
+func newHTTPClient() *http.Client {
+	return &http.Client{Timeout: 10 * time.Second}
+}
+
+// webhookSink posts the event and the message as json.
+type webhookSink struct {
+	url    string
+	tmpl   *template.Template
+	client *http.Client
+}
+
+type webhookPayload struct {
+	Event
+
+	Message string `json:"message"`
+}
+
+func (s *webhookSink) Send(e Event) error {
+	msg, err := render(s.tmpl, e)
+	if err != nil {
+		return err
+	}
+
+	_, err = post(s.client, s.url, webhookPayload{Event: e, Message: msg})
+
+	return err
+}
+
+// chatSink posts the message to the incoming webhook of IM group.
+type chatSink struct {
+	kind   string
+	url    string
+	tmpl   *template.Template
+	client *http.Client
+}
+
+func (s *chatSink) Send(e Event) error {
+	msg, err := render(s.tmpl, e)
+	if err != nil {
+		return err
+	}
+
+	var payload interface{}
+	switch s.kind {
+	case SinkWeCom:
+		payload = map[string]interface{}{
+			"msgtype": "text",
+			"text":    map[string]string{"content": msg},
+		}
+
+	case SinkFeishu:
+		payload = map[string]interface{}{
+			"msg_type": "text",
+			"content":  map[string]string{"text": msg},
+		}
+
+	default:
+		payload = map[string]string{"text": msg}
+	}
+
+	body, err := post(s.client, s.url, payload)
+	if err != nil {
+		return err
+	}
+
+	// WeCom and Feishu respond 200 with the error code in body.
+	var r struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+		Code    int    `json:"code"`
+		Msg     string `json:"msg"`
+	}
+
+	if json.Unmarshal(body, &r) == nil {
+		if r.ErrCode != 0 {
+			return fmt.Errorf("%s responded error %d: %s", s.kind, r.ErrCode, r.ErrMsg)
+		}
+
+		if r.Code != 0 {
+			return fmt.Errorf("%s responded error %d: %s", s.kind, r.Code, r.Msg)
+		}
+	}
+
+	return nil
+}
+
+func post(c *http.Client, url string, payload interface{}) ([]byte, error) {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.Post(url, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("post to %s, status code: %d, body: %s", url, resp.StatusCode, body)
+	}
+
+	return body, nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/opensourceways/repo-owners-cache/repoowners"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const ownersFileName = "OWNERS"
+
+func isOwnersFile(file string) bool {
+	return filepath.Base(file) == ownersFileName
+}
+
+func ownersFilesOf(files []string) []string {
+	var r []string
+	for _, f := range files {
+		if isOwnersFile(f) {
+			r = append(r, f)
+		}
+	}
+	return r
+}
+
+// ownersFileOwner makes the approvers of parent directory be the approvers
+// of an OWNERS file, so that nobody can grant the permission to themselves
+// by changing the OWNERS file. The root approvers are used for the OWNERS file
+// at root, since there is no higher authority.
+type ownersFileOwner struct {
+	repoowners.RepoOwner
+}
+
+func newOwnersFileOwner(owner repoowners.RepoOwner) repoowners.RepoOwner {
+	return ownersFileOwner{RepoOwner: owner}
+}
+
+func (o ownersFileOwner) Approvers(path string) sets.String {
+	if p, ok := o.parentOwnersFile(path); ok {
+		if p == "" {
+			return o.RepoOwner.TopLevelApprovers()
+		}
+		return o.RepoOwner.Approvers(p)
+	}
+
+	return o.RepoOwner.Approvers(path)
+}
+
+func (o ownersFileOwner) LeafApprovers(path string) sets.String {
+	if p, ok := o.parentOwnersFile(path); ok {
+		if p == "" {
+			return o.RepoOwner.TopLevelApprovers()
+		}
+		return o.RepoOwner.LeafApprovers(p)
+	}
+
+	return o.RepoOwner.LeafApprovers(path)
+}
+
+// FindApproverOwnersForFile returns the directory of the OWNERS file whose
+// approvers are the ones of path, so that the fallback and escalation
+// to parent directory also start from the parent of an OWNERS file. It
+// returns empty string for the root approvers.
+func (o ownersFileOwner) FindApproverOwnersForFile(path string) string {
+	if p, ok := o.parentOwnersFile(path); ok {
+		if p == "" {
+			return ""
+		}
+		return o.RepoOwner.FindApproverOwnersForFile(p)
+	}
+
+	return o.RepoOwner.FindApproverOwnersForFile(path)
+}
+
+// parentOwnersFile returns the OWNERS file in the parent directory of
+// the one which includes path if path is an OWNERS file. It returns empty
+// string if path is the OWNERS file at root.
+func (o ownersFileOwner) parentOwnersFile(path string) (string, bool) {
+	if !isOwnersFile(path) {
+		return "", false
+	}
+
+	dir := filepath.Dir(path)
+	if dir == "." {
+		return "", true
+	}
+
+	return filepath.Join(parentDir(dir), ownersFileName), true
+}
+
+func ownersFilesTips(files []string) string {
+	v := ownersFilesOf(files)
+	if len(v) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(
+		"It changes the OWNERS files( %s ), which must be approved by the approvers of parent directory or root.",
+		toReviewerList(v),
+	)
+}
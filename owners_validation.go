@@ -0,0 +1,356 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/opensourceways/community-robot-lib/giteeclient"
+	sdk "github.com/opensourceways/go-gitee/gitee"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	ownersCheckTitle  = "### OWNERS Check"
+	ownersCheckFailed = "The following errors are found in the OWNERS files changed by this PR."
+)
+
+var yamlErrorLine = regexp.MustCompile(`line (\d+):`)
+
+type ownersError struct {
+	file string
+	line int
+	msg  string
+}
+
+// ownersFileChecker checks the OWNERS file by the same rules as repo-owners-cache.
+type ownersFileChecker struct {
+	file    string
+	isLogin func(string) bool
+	errs    []ownersError
+}
+
+func (c *ownersFileChecker) addError(line int, format string, args ...interface{}) {
+	c.errs = append(c.errs, ownersError{
+		file: c.file,
+		line: line,
+		msg:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (c *ownersFileChecker) check(content []byte) []ownersError {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		line := 0
+		if m := yamlErrorLine.FindStringSubmatch(err.Error()); len(m) == 2 {
+			line, _ = strconv.Atoi(m[1])
+		}
+
+		c.addError(line, "invalid yaml: %s", err.Error())
+
+		return c.errs
+	}
+
+	if len(doc.Content) == 0 {
+		c.addError(0, "it is empty")
+
+		return c.errs
+	}
+
+	c.checkConfig(doc.Content[0], true)
+
+	return c.errs
+}
+
+func (c *ownersFileChecker) checkConfig(n *yaml.Node, top bool) {
+	if n.Kind != yaml.MappingNode {
+		c.addError(n.Line, "it should be a map")
+		return
+	}
+
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, v := n.Content[i], n.Content[i+1]
+
+		switch k.Value {
+		case "approvers", "reviewers", "required_reviewers":
+			c.checkLogins(k.Value, v)
+
+		case "labels":
+			c.checkList(k.Value, v)
+
+		case "options":
+			if top {
+				c.checkOptions(v)
+			} else {
+				c.addError(k.Line, "options is only allowed at the top level")
+			}
+
+		case "filters":
+			if top {
+				c.checkFilters(v)
+			} else {
+				c.addError(k.Line, "filters is only allowed at the top level")
+			}
+
+		default:
+			c.addError(k.Line, "unknown field `%s`", k.Value)
+		}
+	}
+}
+
+func (c *ownersFileChecker) checkList(field string, n *yaml.Node) []*yaml.Node {
+	if n.Kind != yaml.SequenceNode {
+		c.addError(n.Line, "`%s` should be a list", field)
+		return nil
+	}
+
+	r := make([]*yaml.Node, 0, len(n.Content))
+	for _, item := range n.Content {
+		if item.Kind != yaml.ScalarNode || item.Value == "" {
+			c.addError(item.Line, "the item of `%s` should be a non-empty string", field)
+			continue
+		}
+
+		r = append(r, item)
+	}
+
+	return r
+}
+
+func (c *ownersFileChecker) checkLogins(field string, n *yaml.Node) {
+	seen := sets.NewString()
+	for _, item := range c.checkList(field, n) {
+		login := normalizeLogin(item.Value)
+
+		if seen.Has(login) {
+			c.addError(item.Line, "`%s` is duplicate in `%s`", item.Value, field)
+			continue
+		}
+		seen.Insert(login)
+
+		if !c.isLogin(login) {
+			c.addError(item.Line, "`%s` is not a collaborator of the repository", item.Value)
+		}
+	}
+}
+
+func (c *ownersFileChecker) checkOptions(n *yaml.Node) {
+	if n.Kind != yaml.MappingNode {
+		c.addError(n.Line, "`options` should be a map")
+		return
+	}
+
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, v := n.Content[i], n.Content[i+1]
+
+		if k.Value != "no_parent_owners" {
+			c.addError(k.Line, "unknown option `%s`", k.Value)
+			continue
+		}
+
+		if _, err := strconv.ParseBool(v.Value); v.Kind != yaml.ScalarNode || err != nil {
+			c.addError(v.Line, "`no_parent_owners` should be true or false")
+		}
+	}
+}
+
+func (c *ownersFileChecker) checkFilters(n *yaml.Node) {
+	if n.Kind != yaml.MappingNode {
+		c.addError(n.Line, "`filters` should be a map")
+		return
+	}
+
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, v := n.Content[i], n.Content[i+1]
+
+		if _, err := regexp.Compile(k.Value); err != nil {
+			c.addError(k.Line, "invalid regular expression `%s`", k.Value)
+		}
+
+		c.checkConfig(v, false)
+	}
+}
+
+// checkOwnersFiles checks the OWNERS files changed by the PR and returns
+// the errors found. It returns nil if no OWNERS file is changed.
+func (bot *robot) checkOwnersFiles(pr iPRInfo, cfg *botConfig) ([]ownersError, error) {
+	org, repo := pr.getOrgAndRepo()
+
+	changes, err := bot.client.GetPullRequestChanges(org, repo, pr.getNumber())
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for i := range changes {
+		f := &changes[i]
+		if isOwnersFile(f.Filename) && f.Status != "removed" && f.Status != "deleted" {
+			files = append(files, f.Filename)
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	cs, err := bot.client.listCollaborators(org, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	collaborators := sets.NewString(cs...)
+	isLogin := func(login string) bool {
+		return collaborators.Has(login) || cfg.teams.isTeam(login)
+	}
+
+	var errs []ownersError
+	for _, f := range files {
+		content, err := bot.getFileContent(org, repo, f, pr.getHeadSHA())
+		if err != nil {
+			return nil, err
+		}
+
+		c := ownersFileChecker{file: f, isLogin: isLogin}
+		errs = append(errs, c.check(content)...)
+	}
+
+	return errs, nil
+}
+
+func (bot *robot) getFileContent(org, repo, path, ref string) ([]byte, error) {
+	v, err := bot.client.GetPathContent(org, repo, path, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	return base64.StdEncoding.DecodeString(strings.ReplaceAll(v.Content, "\n", ""))
+}
+
+// validateOwnersFiles reports the errors of OWNERS files changed by the PR.
+// It returns false if there are errors. The report written before is updated
+// to be valid if the invalid OWNERS files are fixed or not changed any more.
+func (bot *robot) validateOwnersFiles(pr iPRInfo, cfg *botConfig, log *logrus.Entry) (bool, error) {
+	errs, err := bot.checkOwnersFiles(pr, cfg)
+	if err != nil {
+		return true, err
+	}
+
+	if len(errs) > 0 {
+		log.Infof("found %d errors in the OWNERS files", len(errs))
+	}
+
+	return len(errs) == 0, bot.writeOwnersReport(pr, genOwnersReport(errs, cfg.BlockCanReviewOnInvalidOwners))
+}
+
+func (bot *robot) findOwnersReport(pr iPRInfo) ([]giteeclient.BotComment, error) {
+	org, repo := pr.getOrgAndRepo()
+
+	comments, err := bot.client.ListPRComments(org, repo, pr.getNumber())
+	if err != nil {
+		return nil, err
+	}
+
+	v := giteeclient.FindBotComment(comments, bot.botName, isOwnersReport)
+	giteeclient.SortBotComments(v)
+
+	return v, nil
+}
+
+func (bot *robot) writeOwnersReport(pr iPRInfo, s string) error {
+	v, err := bot.findOwnersReport(pr)
+	if err != nil {
+		return err
+	}
+
+	org, repo := pr.getOrgAndRepo()
+
+	n := len(v)
+	if n == 0 {
+		if !strings.Contains(s, ownersCheckFailed) {
+			return nil
+		}
+
+		return bot.client.CreatePRComment(org, repo, pr.getNumber(), s)
+	}
+
+	if v[n-1].Body == s {
+		return nil
+	}
+
+	return bot.client.UpdatePRComment(org, repo, v[n-1].CommentID, s)
+}
+
+// isBlockedByOwners checks whether the can-review label should not be
+// added because of the errors of OWNERS files reported before.
+func (bot *robot) isBlockedByOwners(pr iPRInfo, cfg *botConfig) (bool, error) {
+	if !cfg.BlockCanReviewOnInvalidOwners {
+		return false, nil
+	}
+
+	org, repo := pr.getOrgAndRepo()
+
+	comments, err := bot.client.ListPRComments(org, repo, pr.getNumber())
+	if err != nil {
+		return false, err
+	}
+
+	return bot.isBlockedByOwnersReport(comments, cfg), nil
+}
+
+// isBlockedByOwnersReport is the same as isBlockedByOwners but checks the
+// comments of PR fetched already. Every path which adds the can-review
+// label must consult one of them.
+func (bot *robot) isBlockedByOwnersReport(comments []sdk.PullRequestComments, cfg *botConfig) bool {
+	if !cfg.BlockCanReviewOnInvalidOwners {
+		return false
+	}
+
+	v := giteeclient.FindBotComment(comments, bot.botName, isOwnersReport)
+	if len(v) == 0 {
+		return false
+	}
+
+	giteeclient.SortBotComments(v)
+
+	return strings.Contains(v[len(v)-1].Body, ownersCheckFailed)
+}
+
+func genOwnersReport(errs []ownersError, block bool) string {
+	if len(errs) == 0 {
+		return fmt.Sprintf("%s\n\nAll the OWNERS files changed by this PR are valid.", ownersCheckTitle)
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool {
+		if errs[i].file != errs[j].file {
+			return errs[i].file < errs[j].file
+		}
+		return errs[i].line < errs[j].line
+	})
+
+	b := new(strings.Builder)
+	fmt.Fprintf(b, "%s\n\n%s", ownersCheckTitle, ownersCheckFailed)
+	if block {
+		fmt.Fprintf(b, " The label **%s** will not be added until they are fixed.", labelCanReview)
+	}
+
+	b.WriteString("\n\n| File | Line | Error |\n| --- | --- | --- |\n")
+	for _, e := range errs {
+		line := "-"
+		if e.line > 0 {
+			line = strconv.Itoa(e.line)
+		}
+
+		fmt.Fprintf(b, "| %s | %s | %s |\n", e.file, line, strings.ReplaceAll(e.msg, "|", "\\|"))
+	}
+
+	return b.String()
+}
+
+func isOwnersReport(c string) bool {
+	return strings.HasPrefix(c, ownersCheckTitle)
+}
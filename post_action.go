@@ -1,9 +1,14 @@
 package main
 
 import (
+	"strings"
+
 	"github.com/opensourceways/community-robot-lib/giteeclient"
 	"github.com/opensourceways/repo-owners-cache/repoowners"
 	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/opensourceways/robot-gitee-review-trigger/audit"
 )
 
 type PostAction struct {
@@ -13,7 +18,29 @@ type PostAction struct {
 	c     ghclient
 	owner repoowners.RepoOwner
 
+	// unavailable is the people who should not be suggested.
+	unavailable sets.String
+
+	// ranker ranks the suggested reviewers. It is nil if the
+	// reviewers are picked randomly.
+	ranker *expertiseRanker
+
+	// audit records the label changes and Review Guide. It is nil if audit is disabled.
+	audit *auditRecorder
+
 	isStartingReview bool
+
+	// isDraft is true if the PR is a draft which can't be reviewed.
+	isDraft bool
+
+	// queue keeps the PR in the merge queue. It is nil if the merge queue is disabled.
+	queue *queueUpdater
+
+	// notifier sends the review events. It is nil if there is no sink.
+	notifier *notifier
+
+	// publisher publishes the review state changes. It is nil if publishing is disabled.
+	publisher *statePublisher
 }
 
 type actionParameter struct {
@@ -27,7 +54,7 @@ type actionParameter struct {
 }
 
 func (pa PostAction) do(oldComments []giteeclient.BotComment, lastComment string, rs reviewSummary, r reviewResult, botName string) error {
-	if rs.IsEmpty() {
+	if rs.hasNoCommand() {
 		return nil
 	}
 
@@ -47,19 +74,41 @@ func (pa PostAction) do(oldComments []giteeclient.BotComment, lastComment string
 		}
 	}
 
+	state := newAuditState(&rs, &r)
+
+	// The rejection and the request of change have the same label, so
+	// the former state is told by the status of Review Guide.
+	wasRejected := strings.Contains(oldTips, reviewStatusRejected)
+
+	// The PR which passes review is queued after the labels are added,
+	// see passReview. Otherwise, it may be dropped at the head of queue
+	// for lacking the labels.
+	if !(r.isLGTM && r.isApproved && !pa.isDraft) {
+		pa.queue.update(false)
+	}
+
 	param := &actionParameter{
 		lastComment:       lastComment,
 		needLGTMNum:       r.needLGTMNum,
 		deleteOldComments: deleteOldComments,
 
-		n: newNotificationComment(&rs, oldTips, botName),
+		n: newNotificationComment(&rs, oldTips, botName, pa.cfg.teams).withResult(r).withFiles(pa.pr.files),
 
 		u: func(keep ...string) error {
-			return updatePRLabel(pa.c, pa.pr.info, keep...)
+			// the labels changed are recorded even if it fails partly,
+			// because they will not be taken as the changes when retrying.
+			added, removed, err := updateAndReturnChangedLabels(pa.c, pa.pr.info, keep...)
+
+			pa.audit.recordLabels(added, removed, state)
+			pa.notifier.onReviewChanged(pa.pr.info.hasLabel, wasRejected, added, keep, &rs, &r)
+			pa.publisher.publish(added, removed, state)
+
+			return err
 		},
 	}
 
 	param.writeNotification = func(desc string) error {
+		desc = keepEscalation(desc, oldTips)
 		if desc == oldTips {
 			return nil
 		}
@@ -70,6 +119,9 @@ func (pa PostAction) do(oldComments []giteeclient.BotComment, lastComment string
 			org, repo := info.getOrgAndRepo()
 
 			err = pa.c.CreatePRComment(org, repo, info.getNumber(), desc)
+			if err == nil {
+				pa.audit.record(audit.KindReviewGuide, desc, state)
+			}
 		}
 
 		deleteOldComments()
@@ -81,6 +133,13 @@ func (pa PostAction) do(oldComments []giteeclient.BotComment, lastComment string
 }
 
 func (pa PostAction) handle(param *actionParameter, r reviewResult) error {
+	// The draft can't be reviewed, so all the review labels are removed
+	// as handleDraftChange does when the PR becomes a draft.
+	if pa.isDraft {
+		param.deleteOldComments()
+		return param.u()
+	}
+
 	if r.isRejected {
 		return pa.reject(param)
 	}
@@ -220,6 +279,8 @@ func (pa PostAction) passReview(p *actionParameter) error {
 
 	if err := p.u(labelLGTM, labelApproved); err != nil {
 		mr.AddError(err)
+	} else {
+		p.n = p.n.withQueue(pa.queue.update(true))
 	}
 
 	c := p.n.passReviewComment()
@@ -227,14 +288,22 @@ func (pa PostAction) passReview(p *actionParameter) error {
 		mr.AddError(err)
 	}
 
+	// the PR in merge queue will be merged when it gets to the head
+	if mr.Err() == nil && pa.queue == nil {
+		if err := pa.autoMerge(p.n.botName); err != nil {
+			mr.AddError(err)
+		}
+	}
+
 	return mr.Err()
 }
 
 func (pa PostAction) suggestApprovers(currentApprovers []string) []string {
 	return suggestingApprover{
-		pr:    pa.pr,
-		cfg:   pa.cfg.Review,
-		owner: pa.owner,
+		pr:          pa.pr,
+		cfg:         pa.cfg.Review,
+		owner:       newAvailableOwner(pa.owner, pa.unavailable),
+		unavailable: pa.unavailable,
 	}.suggestApprover(
 		currentApprovers, pa.pr.assignees, pa.log,
 	)
@@ -242,8 +311,8 @@ func (pa PostAction) suggestApprovers(currentApprovers []string) []string {
 
 func (pa PostAction) suggestReviewers() []string {
 	v, err := suggestReviewers(
-		pa.c, pa.owner, pa.pr.info,
-		pa.cfg.Review.TotalNumberOfReviewers, pa.log,
+		pa.c, newAvailableOwner(pa.owner, pa.unavailable), pa.pr.info,
+		pa.cfg.Review.TotalNumberOfReviewers, pa.ranker, pa.log,
 	)
 	if err != nil {
 		pa.log.Error(err)
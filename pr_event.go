@@ -1,12 +1,15 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
 	"github.com/opensourceways/community-robot-lib/giteeclient"
 	sdk "github.com/opensourceways/go-gitee/gitee"
 	"github.com/sirupsen/logrus"
+
+	"github.com/opensourceways/robot-gitee-review-trigger/audit"
 )
 
 type prInfoOnPREvent struct {
@@ -36,6 +39,20 @@ func (pr prInfoOnPREvent) getHeadSHA() string {
 	return pr.e.GetPRHeadSha()
 }
 
+func (pr prInfoOnPREvent) getTitle() string {
+	if v := pr.e.GetPullRequest(); v != nil {
+		return v.Title
+	}
+	return ""
+}
+
+func (pr prInfoOnPREvent) isDraft() bool {
+	if v := pr.e.GetPullRequest(); v != nil {
+		return v.Draft
+	}
+	return false
+}
+
 func (bot *robot) processPREvent(e *sdk.PullRequestEvent, cfg *botConfig, log *logrus.Entry) error {
 	canReview := cfg.CI.NoCI
 
@@ -44,6 +61,10 @@ func (bot *robot) processPREvent(e *sdk.PullRequestEvent, cfg *botConfig, log *l
 		mr := multiError()
 		pr := prInfoOnPREvent{e}
 
+		if _, err := bot.validateOwnersFiles(pr, cfg, log); err != nil {
+			mr.Add(fmt.Sprintf("validate OWNERS files, err:%s", err.Error()))
+		}
+
 		if cfg.NeedWelcome {
 			if err := bot.welcome(pr, cfg); err != nil {
 				mr.Add(fmt.Sprintf("add welcome comment, err:%s", err.Error()))
@@ -58,29 +79,76 @@ func (bot *robot) processPREvent(e *sdk.PullRequestEvent, cfg *botConfig, log *l
 		return mr.Err()
 
 	case sdk.PRActionChangedSourceBranch:
+		mr := multiError()
+		pr := prInfoOnPREvent{e}
+
+		valid, err := bot.validateOwnersFiles(pr, cfg, log)
+		if err != nil {
+			mr.Add(fmt.Sprintf("validate OWNERS files, err:%s", err.Error()))
+		}
+
 		var toKeep []string
-		if canReview {
+		if canReview && (valid || !cfg.BlockCanReviewOnInvalidOwners) {
 			toKeep = append(toKeep, labelCanReview)
 		}
-		return bot.resetToReview(prInfoOnPREvent{e}, cfg, toKeep, log)
+
+		if err := bot.resetToReview(pr, cfg, toKeep, log); err != nil {
+			mr.AddError(err)
+		}
+
+		return mr.Err()
+
+	case sdk.PRActionChangedTargetBranch:
+		return bot.handleTargetBranchChanged(prInfoOnPREvent{e}, cfg, log)
+
+	case sdk.PRActionClosed:
+		return bot.handlePRClosed(prInfoOnPREvent{e}, cfg, log)
+
+	case sdk.PRActionUpdatedLabel:
+		return bot.handleDraftChange(prInfoOnPREvent{e}, cfg, log)
+
+	case sdk.PRActionReopen:
+		return bot.handlePRReopened(prInfoOnPREvent{e}, cfg, log)
+
+	default:
+		// The title or draft flag is changed.
+		if e.GetAction() == "update" {
+			return bot.handleDraftChange(prInfoOnPREvent{e}, cfg, log)
+		}
 	}
 
 	return nil
 }
 
+const welcomeTitle = "Thank your for your pull-request."
+
+func isWelcomeComment(c string) bool {
+	return strings.HasPrefix(strings.TrimSpace(c), welcomeTitle)
+}
+
 func (bot *robot) welcome(pr iPRInfo, cfg *botConfig) error {
 	org, repo := pr.getOrgAndRepo()
 
+	comments, err := bot.client.ListPRComments(org, repo, pr.getNumber())
+	if err != nil {
+		return err
+	}
+
+	if len(giteeclient.FindBotComment(comments, bot.botName, isWelcomeComment)) > 0 {
+		return nil
+	}
+
 	return bot.client.CreatePRComment(
 		org, repo, pr.getNumber(),
 		fmt.Sprintf(
 			`
-Thank your for your pull-request.
+%s
 
 The full list of commands accepted by me can be found at [**here**](%s).
 
 %s
 `,
+			welcomeTitle,
 			cfg.commandsEndpoint,
 			cfg.doc,
 		),
@@ -88,10 +156,27 @@ The full list of commands accepted by me can be found at [**here**](%s).
 }
 
 func (bot *robot) readyToReview(pr iPRInfo, cfg *botConfig, log *logrus.Entry) error {
+	if cfg.Draft.isDraft(pr) {
+		log.Info("skip starting review for the draft")
+		return nil
+	}
+
+	if b, err := bot.isBlockedByOwners(pr, cfg); err != nil || b {
+		return err
+	}
+
 	mr := multiError()
 
 	if err := bot.addLabelOfCanReview(pr); err != nil {
 		mr.AddError(err)
+	} else if !pr.hasLabel(labelCanReview) {
+		bot.newAuditRecorder(pr, pr.getAuthor(), cfg, log).record(
+			audit.KindLabelAdded, labelCanReview, restartedReviewState(),
+		)
+		bot.newNotifier(pr, pr.getAuthor(), cfg, log).notifyReady()
+		bot.newStatePublisher(pr, pr.getAuthor(), log).publish(
+			[]string{labelCanReview}, nil, restartedReviewState(),
+		)
 	}
 
 	if err := bot.addReviewNotification(pr, cfg, log); err != nil {
@@ -111,14 +196,38 @@ func (bot *robot) addLabelOfCanReview(pr iPRInfo) error {
 	return bot.client.AddPRLabel(org, repo, pr.getNumber(), l)
 }
 
+// addReviewNotification writes the Review Guide of starting review if there
+// is not a current one. The guides written before the review starts are the
+// stale ones which failed to be deleted after a push, and they are replaced.
 func (bot *robot) addReviewNotification(pr iPRInfo, cfg *botConfig, log *logrus.Entry) error {
 	org, repo := pr.getOrgAndRepo()
-	owner, err := bot.genRepoOwner(org, repo, pr.getTargetBranch())
+
+	info, err := bot.getReviewInfo(pr)
 	if err != nil {
 		return err
 	}
 
-	reviewers, err := suggestReviewers(bot.client, owner, pr, cfg.Review.TotalNumberOfReviewers, log)
+	var stale []giteeclient.BotComment
+	guides := info.reviewGuides(bot.botName)
+	for i := range guides {
+		if guides[i].CreatedAt.Before(info.t) {
+			stale = append(stale, guides[i])
+		}
+	}
+
+	if len(stale) < len(guides) {
+		return nil
+	}
+
+	owner, err := bot.genRepoOwner(org, repo, pr.getTargetBranch(), cfg)
+	if err != nil {
+		return err
+	}
+
+	reviewers, err := suggestReviewers(
+		bot.client, newAvailableOwner(owner, bot.unavailablePeople(cfg)),
+		pr, cfg.Review.TotalNumberOfReviewers, bot.newExpertiseRanker(cfg), log,
+	)
 	if err != nil {
 		return fmt.Errorf("suggest reviewers, err: %s", err.Error())
 	}
@@ -127,15 +236,32 @@ func (bot *robot) addReviewNotification(pr iPRInfo, cfg *botConfig, log *logrus.
 		return nil
 	}
 
-	s := newNotificationComment(&reviewSummary{}, "", bot.botName).startReviewComment(reviewers)
+	files, err := bot.client.getPullRequestChanges(org, repo, pr.getNumber())
+	if err != nil {
+		return err
+	}
 
-	return bot.client.CreatePRComment(org, repo, pr.getNumber(), s)
+	s := newNotificationComment(&reviewSummary{}, "", bot.botName, cfg.teams).withFiles(files).startReviewComment(reviewers)
+
+	if err := bot.client.CreatePRComment(org, repo, pr.getNumber(), s); err != nil {
+		return err
+	}
+
+	bot.newAuditRecorder(pr, pr.getAuthor(), cfg, log).record(audit.KindReviewGuide, s, nil)
+
+	for i := range stale {
+		_ = bot.client.DeletePRComment(org, repo, stale[i].CommentID)
+	}
+
+	return nil
 }
 
 func (bot *robot) resetToReview(pr iPRInfo, cfg *botConfig, toKeep []string, log *logrus.Entry) error {
+	bot.dropFromQueue(pr, cfg, log, "")
+
 	mr := multiError()
 
-	if err := bot.resetLabels(pr, cfg, toKeep); err != nil {
+	if err := bot.resetLabels(pr, cfg, toKeep, log); err != nil {
 		mr.Add(fmt.Sprintf("remove label when source code changed, err:%s", err.Error()))
 	}
 
@@ -143,18 +269,21 @@ func (bot *robot) resetToReview(pr iPRInfo, cfg *botConfig, toKeep []string, log
 		mr.Add(fmt.Sprintf("delete tips, err:%s", err.Error()))
 	}
 
-	if err := bot.addReviewNotification(pr, cfg, log); err != nil {
+	// The Review Guide is not needed until the OWNERS files are fixed.
+	if b, err := bot.isBlockedByOwners(pr, cfg); err != nil {
 		mr.AddError(err)
+	} else if !b {
+		if err := bot.addReviewNotification(pr, cfg, log); err != nil {
+			mr.AddError(err)
+		}
 	}
 
 	return mr.Err()
 }
 
-func (bot *robot) resetLabels(pr iPRInfo, cfg *botConfig, toKeep []string) error {
-	rmls, err := updateAndReturnRemovedLabels(bot.client, pr, toKeep...)
-	if err != nil {
-		return err
-	}
+func (bot *robot) resetLabels(pr iPRInfo, cfg *botConfig, toKeep []string, log *logrus.Entry) error {
+	// comment the labels removed even if it fails to remove the others
+	rmls, err := bot.removeReviewLabels(pr, cfg, toKeep, restartedReviewState(), log)
 
 	if len(rmls) > 0 {
 		org, repo := pr.getOrgAndRepo()
@@ -167,7 +296,27 @@ func (bot *robot) resetLabels(pr iPRInfo, cfg *botConfig, toKeep []string) error
 		)
 	}
 
-	return nil
+	return err
+}
+
+// removeReviewLabels removes the review labels except the ones to keep
+// and returns the removed ones. The state is the review state after it.
+func (bot *robot) removeReviewLabels(
+	pr iPRInfo, cfg *botConfig, toKeep []string, state json.RawMessage, log *logrus.Entry,
+) ([]string, error) {
+	// use the latest labels, so that the labels will not be removed
+	// and commented again when the event is resent.
+	pr, err := bot.withLatestLabels(pr)
+	if err != nil {
+		return nil, err
+	}
+
+	added, rmls, err := updateAndReturnChangedLabels(bot.client, pr, toKeep...)
+
+	bot.newAuditRecorder(pr, pr.getAuthor(), cfg, log).recordLabels(added, rmls, state)
+	bot.newStatePublisher(pr, pr.getAuthor(), log).publish(added, rmls, state)
+
+	return rmls, err
 }
 
 func (bot *robot) deleteReviewNotification(pr iPRInfo) error {
@@ -0,0 +1,55 @@
+package main
+
+import "sync"
+
+// prLocks serializes the handlings of the same PR, such as the webhook
+// events and the rechecks started by the merge queue, so that they will
+// not update the labels and Review Guide of the PR at the same time.
+type prLocks struct {
+	lock  sync.Mutex
+	locks map[string]*prLock
+}
+
+type prLock struct {
+	sync.Mutex
+
+	// refs is the number of holders and waiters of the lock.
+	refs int
+}
+
+func newPRLocks() *prLocks {
+	return &prLocks{locks: map[string]*prLock{}}
+}
+
+// acquire locks the PR and returns the function to unlock it.
+func (l *prLocks) acquire(key string) func() {
+	l.lock.Lock()
+	v, ok := l.locks[key]
+	if !ok {
+		v = &prLock{}
+		l.locks[key] = v
+	}
+	v.refs++
+	l.lock.Unlock()
+
+	v.Lock()
+
+	return func() {
+		v.Unlock()
+
+		l.lock.Lock()
+		if v.refs--; v.refs == 0 {
+			delete(l.locks, key)
+		}
+		l.lock.Unlock()
+	}
+}
+
+// withPRLock runs f while holding the lock of PR. f must not acquire
+// the lock of the same PR again.
+func (bot *robot) withPRLock(key string, f func() error) error {
+	unlock := bot.prLocks.acquire(key)
+	defer unlock()
+
+	return f()
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/opensourceways/community-robot-lib/giteeclient"
+	"github.com/sirupsen/logrus"
+)
+
+const retargetTitle = "### Target Branch Changed"
+
+func isRetargetComment(c string) bool {
+	return strings.HasPrefix(c, retargetTitle)
+}
+
+// handleTargetBranchChanged restarts the review, because the votes were given
+// under the OWNERS of the old target branch. The comment written here marks
+// the start of new review, see getReviewInfo.
+func (bot *robot) handleTargetBranchChanged(pr iPRInfo, cfg *botConfig, log *logrus.Entry) error {
+	org, repo := pr.getOrgAndRepo()
+
+	err := bot.client.CreatePRComment(
+		org, repo, pr.getNumber(),
+		fmt.Sprintf(
+			"%s\n\nThe target branch is changed to **%s**. The review is restarted and the comments of `/lgtm` and `/approve` before this will not be counted.",
+			retargetTitle, pr.getTargetBranch(),
+		),
+	)
+	if err != nil {
+		return err
+	}
+
+	bot.recheck.cancel(prKey(pr))
+
+	blocked, err := bot.isBlockedByOwners(pr, cfg)
+	if err != nil {
+		return err
+	}
+
+	var toKeep []string
+	if isStartingReview(pr, cfg) && !blocked {
+		toKeep = append(toKeep, labelCanReview)
+	}
+
+	return bot.resetToReview(pr, cfg, toKeep, log)
+}
+
+// handlePRClosed deletes the Review Guide, stops the scheduled
+// recheck and removes the closed PR from the merge queue.
+func (bot *robot) handlePRClosed(pr iPRInfo, cfg *botConfig, log *logrus.Entry) error {
+	bot.recheck.cancel(prKey(pr))
+	bot.drafts.remove(prKey(pr))
+	bot.dropFromQueue(pr, cfg, log, "")
+
+	return bot.deleteReviewNotification(pr)
+}
+
+// handlePRReopened recomputes the whole state of PR, since it may be
+// changed during it was closed.
+func (bot *robot) handlePRReopened(pr iPRInfo, cfg *botConfig, log *logrus.Entry) error {
+	org, repo := pr.getOrgAndRepo()
+
+	return bot.syncPR(org, repo, pr.getNumber(), cfg, log)
+}
+
+// adjustReviewStartTime moves the time after which the review comments are
+// counted to the latest target branch change if it is after code update.
+func (bot *robot) adjustReviewStartTime(ri *reviewInfo) {
+	v := giteeclient.FindBotComment(ri.comments, bot.botName, isRetargetComment)
+	for i := range v {
+		if t := v[i].CreatedAt; t.After(ri.t) {
+			ri.t = t
+		}
+	}
+}
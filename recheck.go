@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+
+	sdk "github.com/opensourceways/go-gitee/gitee"
+	"github.com/opensourceways/repo-owners-cache/repoowners"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/opensourceways/robot-gitee-review-trigger/audit"
+)
+
+// prInfoOnAPI is the info of PR which is fetched by the gitee api.
+// It is used when the review state is recomputed without webhook event.
+type prInfoOnAPI struct {
+	org    string
+	repo   string
+	pr     *sdk.PullRequest
+	labels sets.String
+}
+
+func newPRInfoOnAPI(org, repo string, pr *sdk.PullRequest) prInfoOnAPI {
+	labels := sets.NewString()
+	for i := range pr.Labels {
+		labels.Insert(pr.Labels[i].Name)
+	}
+
+	return prInfoOnAPI{org: org, repo: repo, pr: pr, labels: labels}
+}
+
+func (pr prInfoOnAPI) getOrgAndRepo() (string, string) {
+	return pr.org, pr.repo
+}
+
+func (pr prInfoOnAPI) getNumber() int32 {
+	return pr.pr.Number
+}
+
+func (pr prInfoOnAPI) getTargetBranch() string {
+	if pr.pr.Base == nil {
+		return ""
+	}
+	return pr.pr.Base.Ref
+}
+
+func (pr prInfoOnAPI) hasLabel(l string) bool {
+	return pr.labels.Has(l)
+}
+
+func (pr prInfoOnAPI) getAuthor() string {
+	if pr.pr.User == nil {
+		return ""
+	}
+	return pr.pr.User.Login
+}
+
+func (pr prInfoOnAPI) getHeadSHA() string {
+	if pr.pr.Head == nil {
+		return ""
+	}
+	return pr.pr.Head.Sha
+}
+
+func (pr prInfoOnAPI) getTitle() string {
+	return pr.pr.Title
+}
+
+func (pr prInfoOnAPI) isDraft() bool {
+	return pr.pr.Draft
+}
+
+func (pr prInfoOnAPI) getAssignees() []string {
+	v := pr.pr.Assignees
+	as := make([]string, 0, len(v))
+	for i := range v {
+		as = append(as, normalizeLogin(v[i].Login))
+	}
+	return as
+}
+
+func (pr prInfoOnAPI) isOpen() bool {
+	return pr.pr.State == "open"
+}
+
+func (bot *robot) getPRInfo(org, repo string, number int32) (prInfoOnAPI, error) {
+	v, err := bot.client.GetGiteePullRequest(org, repo, number)
+	if err != nil {
+		return prInfoOnAPI{}, err
+	}
+
+	return newPRInfoOnAPI(org, repo, &v), nil
+}
+
+// recheckPR fetches the latest PR and recomputes the review state of it.
+func (bot *robot) recheckPR(org, repo string, number int32, cfg *botConfig, log *logrus.Entry) error {
+	pr, err := bot.getPRInfo(org, repo, number)
+	if err != nil {
+		return err
+	}
+
+	if !pr.isOpen() {
+		return nil
+	}
+
+	_, _, err = bot.reviewPR(pr, pr.getAssignees(), cfg, log)
+
+	return err
+}
+
+// reviewContext is everything needed to compute the review state of a PR.
+type reviewContext struct {
+	owner repoowners.RepoOwner
+	pr    *pullRequest
+	info  reviewInfo
+	stats *reviewStats
+}
+
+func (bot *robot) genReviewContext(prInfo iPRInfo, assignees []string, cfg *botConfig) (
+	ctx reviewContext, err error,
+) {
+	org, repo := prInfo.getOrgAndRepo()
+	if ctx.owner, err = bot.genRepoOwner(org, repo, prInfo.getTargetBranch(), cfg); err != nil {
+		return
+	}
+
+	pr, err := bot.genPullRequest(prInfo, assignees, ctx.owner)
+	if err != nil {
+		return
+	}
+	ctx.pr = &pr
+
+	if ctx.info, err = bot.getReviewInfo(prInfo); err != nil {
+		return
+	}
+
+	ctx.stats, err = bot.newReviewStats(ctx.pr, ctx.owner, cfg)
+
+	return
+}
+
+func (bot *robot) newPostAction(ctx reviewContext, cfg *botConfig, actor string, log *logrus.Entry) PostAction {
+	prInfo := ctx.pr.info
+
+	return PostAction{
+		c:                bot.client,
+		cfg:              cfg,
+		owner:            ctx.owner,
+		log:              log,
+		pr:               ctx.pr,
+		unavailable:      bot.unavailablePeople(cfg),
+		ranker:           bot.newExpertiseRanker(cfg),
+		audit:            bot.newAuditRecorder(prInfo, actor, cfg, log),
+		isStartingReview: isStartingReview(prInfo, cfg) && !bot.isBlockedByOwnersReport(ctx.info.comments, cfg),
+		isDraft:          cfg.Draft.isDraft(prInfo),
+		queue:            bot.newQueueUpdater(prInfo, cfg, log),
+		notifier:         bot.newNotifier(prInfo, actor, cfg, log),
+		publisher:        bot.newStatePublisher(prInfo, actor, log),
+	}
+}
+
+// reviewPR recomputes the review state of PR from the comments and
+// updates the labels and Review Guide as handling the review comment.
+func (bot *robot) reviewPR(prInfo iPRInfo, assignees []string, cfg *botConfig, log *logrus.Entry) (
+	reviewSummary, reviewResult, error,
+) {
+	ctx, err := bot.genReviewContext(prInfo, assignees, cfg)
+	if err != nil {
+		return reviewSummary{}, reviewResult{}, err
+	}
+
+	return bot.applyReview(ctx, cfg, "", "", log)
+}
+
+// applyReview computes the review state and updates the labels and
+// Review Guide. The cmd is the review command which triggers it, if any.
+func (bot *robot) applyReview(ctx reviewContext, cfg *botConfig, actor, cmd string, log *logrus.Entry) (
+	reviewSummary, reviewResult, error,
+) {
+	rs, rr := ctx.info.doStats(ctx.stats, bot.botName)
+
+	bot.scheduleRecheck(ctx.pr.info, cfg, rr, log)
+
+	pa := bot.newPostAction(ctx, cfg, actor, log)
+
+	if cmd != "" {
+		pa.audit.record(audit.KindCommand, cmd, newAuditState(&rs, &rr))
+	}
+
+	return rs, rr, pa.do(ctx.info.reviewGuides(bot.botName), cmd, rs, rr, bot.botName)
+}
+
+func isStartingReview(pr iPRInfo, cfg *botConfig) bool {
+	return (cfg.CI.NoCI || pr.hasLabel(cfg.CI.LabelForCIPassed)) && !cfg.Draft.isDraft(pr)
+}
+
+func prKey(pr iPRInfo) string {
+	org, repo := pr.getOrgAndRepo()
+
+	return fmt.Sprintf("%s/%s/%d", org, repo, pr.getNumber())
+}
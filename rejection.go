@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	rejectionModeVeto     = "veto"
+	rejectionModeRootVeto = "root_veto"
+	rejectionModeMajority = "majority"
+)
+
+type rejectionConfig struct {
+	// Mode is how to resolve the /reject of approvers.
+	// veto: any approver who commented /reject rejects the PR. It is the default.
+	// root_veto: only the root approvers can reject the PR.
+	// majority: the PR is rejected if there are more approvers who commented
+	//   /reject than the ones who commented /approve.
+	Mode string `json:"mode,omitempty"`
+
+	// Sticky specifies whether the /reject commented before the latest
+	// push still counts until the approver comments another review command.
+	// Otherwise the rejection expires after the author pushes a change,
+	// as the other review commands do.
+	Sticky bool `json:"sticky,omitempty"`
+}
+
+func (c *rejectionConfig) setDefault() {
+	if c.Mode == "" {
+		c.Mode = rejectionModeVeto
+	}
+}
+
+func (c *rejectionConfig) validate() error {
+	switch c.Mode {
+	case rejectionModeVeto, rejectionModeRootVeto, rejectionModeMajority:
+		return nil
+	}
+
+	return fmt.Errorf("unknown mode of rejection: %s", c.Mode)
+}
+
+// rejectionPolicy decides whether the PR is rejected by the approvers.
+type rejectionPolicy interface {
+	// isRejected returns whether the PR is rejected. If not, it returns
+	// the rejecters who are overruled.
+	isRejected(rejecters, approvers []string) (bool, []string)
+}
+
+func newRejectionPolicy(cfg rejectionConfig, rootApprovers sets.String) rejectionPolicy {
+	switch cfg.Mode {
+	case rejectionModeRootVeto:
+		return rootVetoPolicy{rootApprovers: rootApprovers}
+	case rejectionModeMajority:
+		return majorityPolicy{}
+	}
+
+	return vetoPolicy{}
+}
+
+type vetoPolicy struct{}
+
+func (p vetoPolicy) isRejected(rejecters, approvers []string) (bool, []string) {
+	return len(rejecters) > 0, nil
+}
+
+type rootVetoPolicy struct {
+	rootApprovers sets.String
+}
+
+func (p rootVetoPolicy) isRejected(rejecters, approvers []string) (bool, []string) {
+	for _, item := range rejecters {
+		if p.rootApprovers.Has(item) {
+			return true, nil
+		}
+	}
+
+	return false, rejecters
+}
+
+type majorityPolicy struct{}
+
+func (p majorityPolicy) isRejected(rejecters, approvers []string) (bool, []string) {
+	if len(rejecters) > len(approvers) {
+		return true, nil
+	}
+
+	return false, rejecters
+}
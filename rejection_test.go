@@ -0,0 +1,146 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	sdk "github.com/opensourceways/go-gitee/gitee"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestRejectionPolicy(t *testing.T) {
+	root := sets.NewString("root")
+
+	cases := []struct {
+		name      string
+		mode      string
+		rejecters []string
+		approvers []string
+		rejected  bool
+		overruled []string
+	}{
+		{"veto by anyone", rejectionModeVeto, []string{"a"}, []string{"b", "c"}, true, nil},
+		{"veto without rejecters", rejectionModeVeto, nil, []string{"b"}, false, nil},
+		{"root veto by root", rejectionModeRootVeto, []string{"a", "root"}, nil, true, nil},
+		{"root veto by others", rejectionModeRootVeto, []string{"a"}, nil, false, []string{"a"}},
+		{"majority of rejecters", rejectionModeMajority, []string{"a", "b"}, []string{"c"}, true, nil},
+		{"tie of majority", rejectionModeMajority, []string{"a"}, []string{"c"}, false, []string{"a"}},
+		{"majority of approvers", rejectionModeMajority, []string{"a"}, []string{"c", "d"}, false, []string{"a"}},
+	}
+
+	for _, c := range cases {
+		p := newRejectionPolicy(rejectionConfig{Mode: c.mode}, root)
+
+		rejected, overruled := p.isRejected(c.rejecters, c.approvers)
+		if rejected != c.rejected {
+			t.Errorf("%s: expect rejected=%v, but got %v", c.name, c.rejected, rejected)
+		}
+
+		if !sets.NewString(overruled...).Equal(sets.NewString(c.overruled...)) {
+			t.Errorf("%s: expect overruled=%v, but got %v", c.name, c.overruled, overruled)
+		}
+	}
+}
+
+func TestRejectionConfig(t *testing.T) {
+	c := rejectionConfig{}
+	c.setDefault()
+	if c.Mode != rejectionModeVeto {
+		t.Errorf("expect the default mode to be %s, but got %s", rejectionModeVeto, c.Mode)
+	}
+
+	c.Mode = "unknown"
+	if c.validate() == nil {
+		t.Error("expect an error for unknown mode")
+	}
+}
+
+func TestGenReviewResultWithRejection(t *testing.T) {
+	cfg := reviewConfig{Rejection: rejectionConfig{Mode: rejectionModeMajority}}
+	cfg.setDefault()
+
+	yes := func([]string, int) bool { return true }
+	noRule := func([]string) string { return "" }
+
+	r := reviewSummary{
+		agreedApprovers:    []string{"a", "b"},
+		disagreedApprovers: []string{"c"},
+	}
+
+	rr := genReviewResult(r, yes, yes, noRule, time.Time{}, newRejectionPolicy(cfg.Rejection, nil), cfg)
+	if rr.isRejected || !rr.isApproved {
+		t.Errorf("expect the rejection to be overruled, but got %+v", rr)
+	}
+	if len(rr.overruledRejecters) != 1 || rr.overruledRejecters[0] != "c" {
+		t.Errorf("expect c to be overruled, but got %v", rr.overruledRejecters)
+	}
+
+	cfg.Rejection.Mode = rejectionModeVeto
+	rr = genReviewResult(r, yes, yes, noRule, time.Time{}, newRejectionPolicy(cfg.Rejection, nil), cfg)
+	if !rr.isRejected || rr.isApproved {
+		t.Errorf("expect the PR to be rejected, but got %+v", rr)
+	}
+}
+
+type testPRInfo struct {
+	iPRInfo
+	author string
+}
+
+func (pr testPRInfo) getAuthor() string {
+	return pr.author
+}
+
+func TestStickyRejection(t *testing.T) {
+	push := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	comment := func(author, body string, hours int) sdk.PullRequestComments {
+		return sdk.PullRequestComments{
+			Body:      body,
+			User:      &sdk.UserBasic{Login: author},
+			UpdatedAt: push.Add(time.Duration(hours) * time.Hour).Format(time.RFC3339),
+		}
+	}
+
+	comments := []sdk.PullRequestComments{
+		comment("a", "/reject", -2),
+		comment("b", "/reject", -2),
+		comment("b", "/approve", -1),
+		comment("c", "/reject", -1),
+		comment("c", "/approve", 1),
+		comment("d", "/lgtm", 1),
+	}
+
+	approvers := map[string]sets.String{
+		"a": sets.NewString("f"), "b": sets.NewString("f"), "c": sets.NewString("f"),
+	}
+
+	stats := func(sticky bool) reviewStats {
+		return reviewStats{
+			pr: &pullRequest{
+				info:            testPRInfo{author: "author"},
+				approverFileMap: approvers,
+			},
+			cfg:       reviewConfig{Rejection: rejectionConfig{Sticky: sticky}},
+			reviewers: sets.NewString("a", "b", "c", "d"),
+		}
+	}
+
+	got := func(cmds []reviewCommand) map[string]string {
+		r := map[string]string{}
+		for _, c := range cmds {
+			r[c.author] = c.command
+		}
+		return r
+	}
+
+	cmds, _ := stats(false).filterComments(comments, push, "bot")
+	if v := got(cmds); len(v) != 2 || v["c"] != cmdAPPROVE || v["d"] != cmdLGTM {
+		t.Errorf("expect the rejections to expire after push, but got %v", v)
+	}
+
+	cmds, _ = stats(true).filterComments(comments, push, "bot")
+	v := got(cmds)
+	if len(v) != 3 || v["a"] != cmdReject || v["c"] != cmdAPPROVE || v["d"] != cmdLGTM {
+		t.Errorf("expect only the latest rejection of a to stick, but got %v", v)
+	}
+}
@@ -0,0 +1,60 @@
+package report
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/opensourceways/robot-gitee-review-trigger/audit"
+)
+
+// NewHandler returns the handler which responds the report of records
+// selected by the query of org, repo and days. The format is specified
+// by the query of format, which is json by default.
+func NewHandler(s audit.Store, opt Options) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		q := r.URL.Query()
+
+		f := audit.Filter{Org: q.Get("org"), Repo: q.Get("repo")}
+		if v := q.Get("days"); v != "" {
+			days, err := strconv.Atoi(v)
+			if err != nil || days <= 0 {
+				http.Error(w, "invalid days", http.StatusBadRequest)
+				return
+			}
+
+			f.Since = time.Now().AddDate(0, 0, -days)
+		}
+
+		format := q.Get("format")
+		if format == "" {
+			format = FormatJSON
+		}
+
+		records, err := s.List(f)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		buf := new(bytes.Buffer)
+		if err := Generate(records, opt).Write(buf, format); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if format == FormatCSV {
+			w.Header().Set("Content-Type", "text/csv")
+		} else {
+			w.Header().Set("Content-Type", "application/json")
+		}
+
+		_, _ = w.Write(buf.Bytes())
+	})
+}
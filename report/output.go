@@ -0,0 +1,85 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+func (r Report) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatJSON:
+		return r.WriteJSON(w)
+	case FormatCSV:
+		return r.WriteCSV(w)
+	}
+
+	return fmt.Errorf("unknown format: %s", format)
+}
+
+func (r Report) WriteJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+
+	return e.Encode(r)
+}
+
+// WriteCSV writes the stats of repos and reviewers as two tables
+// which are separated by an empty line.
+func (r Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	f := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	d := func(v Durations) []string {
+		return []string{strconv.Itoa(v.Count), f(v.Median), f(v.P90)}
+	}
+
+	rows := [][]string{{
+		"repo", "pull_requests",
+		"first_lgtm_count", "first_lgtm_median_hours", "first_lgtm_p90_hours",
+		"approved_count", "approved_median_hours", "approved_p90_hours",
+		"pass_review_count", "pass_review_median_hours", "pass_review_p90_hours",
+		"rejection_rate",
+	}}
+
+	for _, item := range r.Repos {
+		row := []string{item.Repo, strconv.Itoa(item.PullRequests)}
+		row = append(row, d(item.TimeToFirstLGTM)...)
+		row = append(row, d(item.TimeToApproved)...)
+		row = append(row, d(item.TimeToPassReview)...)
+		row = append(row, f(item.RejectionRate))
+
+		rows = append(rows, row)
+	}
+
+	rows = append(rows, nil, []string{
+		"reviewer", "reviews", "rejections", "rejection_rate",
+		"review_count", "review_median_hours", "review_p90_hours",
+	})
+
+	for _, item := range r.Reviewers {
+		row := []string{
+			item.Reviewer, strconv.Itoa(item.Reviews),
+			strconv.Itoa(item.Rejections), f(item.RejectionRate),
+		}
+		row = append(row, d(item.TimeToReview)...)
+
+		rows = append(rows, row)
+	}
+
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+
+	return cw.Error()
+}
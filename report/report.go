@@ -0,0 +1,262 @@
+package report
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/opensourceways/robot-gitee-review-trigger/audit"
+)
+
+// Options describes the labels and commands used by the robot.
+type Options struct {
+	LabelCanReview string
+	LabelLGTM      string
+	LabelApproved  string
+
+	CmdLGTM      string
+	NegativeCmds []string
+}
+
+// Durations is the statistics of durations in hours.
+type Durations struct {
+	Count  int     `json:"count"`
+	Median float64 `json:"median_hours"`
+	P90    float64 `json:"p90_hours"`
+}
+
+func newDurations(v []time.Duration) Durations {
+	n := len(v)
+	if n == 0 {
+		return Durations{}
+	}
+
+	sort.Slice(v, func(i, j int) bool { return v[i] < v[j] })
+
+	percentile := func(p float64) float64 {
+		i := int(math.Ceil(p*float64(n))) - 1
+		if i < 0 {
+			i = 0
+		}
+		return math.Round(v[i].Hours()*100) / 100
+	}
+
+	return Durations{
+		Count:  n,
+		Median: percentile(0.5),
+		P90:    percentile(0.9),
+	}
+}
+
+type RepoStats struct {
+	Repo             string    `json:"repo"`
+	PullRequests     int       `json:"pull_requests"`
+	TimeToFirstLGTM  Durations `json:"time_to_first_lgtm"`
+	TimeToApproved   Durations `json:"time_to_approved"`
+	TimeToPassReview Durations `json:"time_to_pass_review"`
+
+	// RejectionRate is the ratio of PRs which got /reject or /lbtm.
+	RejectionRate float64 `json:"rejection_rate"`
+}
+
+type ReviewerStats struct {
+	Reviewer   string `json:"reviewer"`
+	Reviews    int    `json:"reviews"`
+	Rejections int    `json:"rejections"`
+
+	// RejectionRate is the ratio of /reject and /lbtm in all the reviews.
+	RejectionRate float64 `json:"rejection_rate"`
+
+	// TimeToReview is the duration from the PR being ready for review
+	// to the first review command of the reviewer on it.
+	TimeToReview Durations `json:"time_to_review"`
+}
+
+type Report struct {
+	Repos     []RepoStats     `json:"repos"`
+	Reviewers []ReviewerStats `json:"reviewers"`
+}
+
+type prTimeline struct {
+	ready       time.Time
+	firstLGTM   time.Time
+	approved    time.Time
+	passReview  time.Time
+	rejected    bool
+	labels      sets.String
+	firstReview map[string]time.Time
+}
+
+func (t *prTimeline) setOnce(v *time.Time, at time.Time) {
+	if v.IsZero() && !t.ready.IsZero() {
+		*v = at
+	}
+}
+
+type reviewerRecord struct {
+	reviews    int
+	rejections int
+	durations  []time.Duration
+}
+
+// Generate computes the statistics from the audit records.
+func Generate(records []audit.Record, opt Options) Report {
+	negatives := sets.NewString(opt.NegativeCmds...)
+
+	prs := map[string]*prTimeline{}
+	repoOfPR := map[string]string{}
+	reviewers := map[string]*reviewerRecord{}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Time.Before(records[j].Time)
+	})
+
+	for i := range records {
+		r := &records[i]
+
+		repo := r.Org + "/" + r.Repo
+		key := fmt.Sprintf("%s/%d", repo, r.Number)
+
+		t, ok := prs[key]
+		if !ok {
+			t = &prTimeline{labels: sets.NewString(), firstReview: map[string]time.Time{}}
+			prs[key] = t
+			repoOfPR[key] = repo
+		}
+
+		switch r.Kind {
+		case audit.KindLabelAdded:
+			labels := strings.Split(r.Detail, ",")
+			t.labels.Insert(labels...)
+
+			if t.ready.IsZero() && t.labels.Has(opt.LabelCanReview) {
+				t.ready = r.Time
+			}
+
+			if t.labels.Has(opt.LabelApproved) {
+				t.setOnce(&t.approved, r.Time)
+			}
+
+			if t.labels.HasAll(opt.LabelLGTM, opt.LabelApproved) {
+				t.setOnce(&t.passReview, r.Time)
+			}
+
+		case audit.KindLabelRemoved:
+			t.labels.Delete(strings.Split(r.Detail, ",")...)
+
+		case audit.KindCommand:
+			who := strings.ToLower(r.Actor)
+
+			rr, ok := reviewers[who]
+			if !ok {
+				rr = &reviewerRecord{}
+				reviewers[who] = rr
+			}
+
+			rr.reviews++
+
+			if negatives.Has(r.Detail) {
+				rr.rejections++
+				t.rejected = true
+			}
+
+			if r.Detail == opt.CmdLGTM {
+				t.setOnce(&t.firstLGTM, r.Time)
+			}
+
+			if _, ok := t.firstReview[who]; !ok && !t.ready.IsZero() {
+				t.firstReview[who] = r.Time
+				rr.durations = append(rr.durations, r.Time.Sub(t.ready))
+			}
+		}
+	}
+
+	return Report{
+		Repos:     genRepoStats(prs, repoOfPR),
+		Reviewers: genReviewerStats(reviewers),
+	}
+}
+
+func genRepoStats(prs map[string]*prTimeline, repoOfPR map[string]string) []RepoStats {
+	type repoRecord struct {
+		prs        int
+		rejected   int
+		firstLGTM  []time.Duration
+		approved   []time.Duration
+		passReview []time.Duration
+	}
+
+	repos := map[string]*repoRecord{}
+	for key, t := range prs {
+		if t.ready.IsZero() {
+			continue
+		}
+
+		repo := repoOfPR[key]
+		rr, ok := repos[repo]
+		if !ok {
+			rr = &repoRecord{}
+			repos[repo] = rr
+		}
+
+		rr.prs++
+		if t.rejected {
+			rr.rejected++
+		}
+
+		add := func(v []time.Duration, at time.Time) []time.Duration {
+			if at.IsZero() {
+				return v
+			}
+			return append(v, at.Sub(t.ready))
+		}
+
+		rr.firstLGTM = add(rr.firstLGTM, t.firstLGTM)
+		rr.approved = add(rr.approved, t.approved)
+		rr.passReview = add(rr.passReview, t.passReview)
+	}
+
+	r := make([]RepoStats, 0, len(repos))
+	for repo, item := range repos {
+		r = append(r, RepoStats{
+			Repo:             repo,
+			PullRequests:     item.prs,
+			TimeToFirstLGTM:  newDurations(item.firstLGTM),
+			TimeToApproved:   newDurations(item.approved),
+			TimeToPassReview: newDurations(item.passReview),
+			RejectionRate:    ratio(item.rejected, item.prs),
+		})
+	}
+
+	sort.Slice(r, func(i, j int) bool { return r[i].Repo < r[j].Repo })
+
+	return r
+}
+
+func genReviewerStats(reviewers map[string]*reviewerRecord) []ReviewerStats {
+	r := make([]ReviewerStats, 0, len(reviewers))
+	for who, item := range reviewers {
+		r = append(r, ReviewerStats{
+			Reviewer:      who,
+			Reviews:       item.reviews,
+			Rejections:    item.rejections,
+			RejectionRate: ratio(item.rejections, item.reviews),
+			TimeToReview:  newDurations(item.durations),
+		})
+	}
+
+	sort.Slice(r, func(i, j int) bool { return r[i].Reviewer < r[j].Reviewer })
+
+	return r
+}
+
+func ratio(a, b int) float64 {
+	if b == 0 {
+		return 0
+	}
+	return math.Round(float64(a)/float64(b)*10000) / 10000
+}
@@ -0,0 +1,77 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/opensourceways/robot-gitee-review-trigger/audit"
+)
+
+var testOptions = Options{
+	LabelCanReview: "can-review",
+	LabelLGTM:      "lgtm",
+	LabelApproved:  "approved",
+	CmdLGTM:        "LGTM",
+	NegativeCmds:   []string{"REJECT", "LBTM"},
+}
+
+func TestGenerate(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(hours int) time.Time {
+		return start.Add(time.Duration(hours) * time.Hour)
+	}
+
+	pr := func(number int32, kind, actor, detail string, hours int) audit.Record {
+		return audit.Record{
+			Org: "o", Repo: "r", Number: number,
+			Kind: kind, Actor: actor, Detail: detail, Time: at(hours),
+		}
+	}
+
+	records := []audit.Record{
+		pr(1, audit.KindLabelAdded, "", "can-review", 0),
+		pr(1, audit.KindCommand, "Alice", "LGTM", 2),
+		pr(1, audit.KindLabelAdded, "", "can-review,lgtm", 2),
+		pr(1, audit.KindCommand, "bob", "APPROVE", 4),
+		pr(1, audit.KindLabelAdded, "", "lgtm,approved", 4),
+
+		pr(2, audit.KindLabelAdded, "", "can-review", 10),
+		pr(2, audit.KindCommand, "bob", "REJECT", 20),
+	}
+
+	r := Generate(records, testOptions)
+
+	if len(r.Repos) != 1 {
+		t.Fatalf("expect 1 repo, but got %d", len(r.Repos))
+	}
+
+	repo := r.Repos[0]
+	if repo.Repo != "o/r" || repo.PullRequests != 2 {
+		t.Errorf("unexpected repo stats: %+v", repo)
+	}
+
+	if v := repo.TimeToFirstLGTM; v.Count != 1 || v.Median != 2 {
+		t.Errorf("unexpected time to first lgtm: %+v", v)
+	}
+
+	if v := repo.TimeToPassReview; v.Count != 1 || v.Median != 4 {
+		t.Errorf("unexpected time to pass review: %+v", v)
+	}
+
+	if repo.RejectionRate != 0.5 {
+		t.Errorf("expect rejection rate 0.5, but got %v", repo.RejectionRate)
+	}
+
+	if len(r.Reviewers) != 2 {
+		t.Fatalf("expect 2 reviewers, but got %d", len(r.Reviewers))
+	}
+
+	bob := r.Reviewers[1]
+	if bob.Reviewer != "bob" || bob.Reviews != 2 || bob.Rejections != 1 {
+		t.Errorf("unexpected stats of bob: %+v", bob)
+	}
+
+	if v := bob.TimeToReview; v.Count != 2 || v.Median != 4 || v.P90 != 10 {
+		t.Errorf("unexpected time to review of bob: %+v", v)
+	}
+}
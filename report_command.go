@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/opensourceways/community-robot-lib/secret"
+	"github.com/sirupsen/logrus"
+
+	"github.com/opensourceways/robot-gitee-review-trigger/audit"
+	"github.com/opensourceways/robot-gitee-review-trigger/report"
+)
+
+const subCmdReport = "report"
+
+func reportOptions() report.Options {
+	return report.Options{
+		LabelCanReview: labelCanReview,
+		LabelLGTM:      labelLGTM,
+		LabelApproved:  labelApproved,
+		CmdLGTM:        cmdLGTM,
+		NegativeCmds:   negativeCmds.UnsortedList(),
+	}
+}
+
+type reportCmdOptions struct {
+	audit  auditOptions
+	org    string
+	repo   string
+	days   int
+	format string
+}
+
+func (o *reportCmdOptions) validate() error {
+	if o.audit.store == "" {
+		return fmt.Errorf("missing audit-store")
+	}
+
+	if o.days < 0 {
+		return fmt.Errorf("days must not be negative")
+	}
+
+	return o.audit.validate()
+}
+
+// runReport generates the report of review latency and throughput
+// from the audit records and writes it to stdout.
+func runReport(args []string) {
+	var o reportCmdOptions
+
+	fs := flag.NewFlagSet(subCmdReport, flag.ExitOnError)
+	o.audit.addFlags(fs)
+	fs.StringVar(&o.org, "org", "", "the org to report. All the orgs are reported if it is empty.")
+	fs.StringVar(&o.repo, "repo", "", "the repo to report. All the repos are reported if it is empty.")
+	fs.IntVar(&o.days, "days", 0, "the number of recent days to report. All the records are used if it is 0.")
+	fs.StringVar(&o.format, "format", report.FormatJSON, "the format of report, json or csv.")
+	_ = fs.Parse(args)
+
+	if err := o.validate(); err != nil {
+		logrus.WithError(err).Fatal("Invalid options")
+	}
+
+	secretAgent := new(secret.Agent)
+	if err := secretAgent.Start(o.audit.secrets()); err != nil {
+		logrus.WithError(err).Fatal("Error starting secret agent.")
+	}
+
+	defer secretAgent.Stop()
+
+	s, err := o.audit.newStore(secretAgent, true)
+	if err != nil {
+		logrus.WithError(err).Fatal("init audit store")
+	}
+
+	defer s.Close()
+
+	f := audit.Filter{Org: o.org, Repo: o.repo}
+	if o.days > 0 {
+		f.Since = time.Now().AddDate(0, 0, -o.days)
+	}
+
+	records, err := s.List(f)
+	if err != nil {
+		logrus.WithError(err).Fatal("list audit records")
+	}
+
+	if err := report.Generate(records, reportOptions()).Write(os.Stdout, o.format); err != nil {
+		logrus.WithError(err).Fatal("write report")
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// pathPatterns is the patterns of files.
+// A pattern ending with `/` matches all the files under that directory.
+// A pattern without `/` matches the base name of file, for example,
+// `LICENSE*` matches `LICENSE` and `docs/LICENSE.txt`. Otherwise, it
+// matches the whole path of file.
+type pathPatterns []string
+
+func (ps pathPatterns) validate() error {
+	for _, p := range ps {
+		if _, err := filepath.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid path pattern %s", p)
+		}
+	}
+
+	return nil
+}
+
+func (ps pathPatterns) match(file string) bool {
+	for _, p := range ps {
+		if strings.HasSuffix(p, "/") {
+			if strings.HasPrefix(file, p) {
+				return true
+			}
+			continue
+		}
+
+		name := file
+		if !strings.Contains(p, "/") {
+			name = filepath.Base(file)
+		}
+
+		if b, _ := filepath.Match(p, name); b {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (ps pathPatterns) matchAny(files []string) bool {
+	for _, f := range files {
+		if ps.match(f) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type requiredApprovalConfig struct {
+	// Name is the name of rule which will be shown in the Review Guide.
+	Name string `json:"name" required:"true"`
+
+	// Paths is the patterns of files which the rule applies to.
+	Paths pathPatterns `json:"paths" required:"true"`
+
+	// Approvers is the people or teams who can approve the files.
+	Approvers []string `json:"approvers" required:"true"`
+
+	// NumberOfApprovers is the min number of Approvers who commented /approve.
+	NumberOfApprovers int `json:"number_of_approvers,omitempty"`
+}
+
+func (r *requiredApprovalConfig) setDefault() {
+	if r.NumberOfApprovers <= 0 {
+		r.NumberOfApprovers = 1
+	}
+}
+
+func (r *requiredApprovalConfig) validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("missing name of required approval")
+	}
+
+	if len(r.Paths) == 0 {
+		return fmt.Errorf("missing paths of required approval %s", r.Name)
+	}
+
+	if err := r.Paths.validate(); err != nil {
+		return fmt.Errorf("%s of required approval %s", err.Error(), r.Name)
+	}
+
+	if len(r.Approvers) == 0 {
+		return fmt.Errorf("missing approvers of required approval %s", r.Name)
+	}
+
+	return nil
+}
+
+type requiredApproval struct {
+	*requiredApprovalConfig
+
+	approvers sets.String
+}
+
+type requiredApprovals []requiredApproval
+
+func (c *botConfig) requiredApprovals() requiredApprovals {
+	items := c.Review.RequiredApprovals
+	if len(items) == 0 {
+		return nil
+	}
+
+	r := make(requiredApprovals, 0, len(items))
+	for i := range items {
+		as := sets.NewString()
+		for _, item := range items[i].Approvers {
+			as.Insert(normalizeLogin(item))
+		}
+
+		r = append(r, requiredApproval{
+			requiredApprovalConfig: &items[i],
+			approvers:              c.teams.expand(as),
+		})
+	}
+
+	return r
+}
+
+// approversOf returns the approvers of rules which apply to the files.
+// They are not the approvers in OWNERS and only count for the rules.
+func (rs requiredApprovals) approversOf(files []string) sets.String {
+	r := sets.NewString()
+	for i := range rs {
+		if rs[i].Paths.matchAny(files) {
+			r = r.Union(rs[i].approvers)
+		}
+	}
+	return r
+}
+
+// unmet returns the descriptions of all the rules which are not satisfied
+// by the approvers. It returns empty string if all rules are satisfied.
+func (rs requiredApprovals) unmet(files []string, agreedApprovers []string) string {
+	agreed := sets.NewString(agreedApprovers...)
+
+	var r []string
+	for i := range rs {
+		item := &rs[i]
+		if !item.Paths.matchAny(files) {
+			continue
+		}
+
+		n := item.NumberOfApprovers - item.approvers.Intersection(agreed).Len()
+		if n <= 0 {
+			continue
+		}
+
+		r = append(r, fmt.Sprintf(
+			"The rule *%s* requires **%d** more of these approvers( %s ) to comment /approve.",
+			item.Name, n, strings.Join(item.Approvers, notificationReviewersSpliter),
+		))
+	}
+
+	return strings.Join(r, notificationLineSpliter)
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"errors"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	retryBaseDelay = 30 * time.Second
+	retryMaxDelay  = 10 * time.Minute
+)
+
+// retryQueue retries the failed handling of PR with exponential backoff
+// and jitter. There is at most one pending retry for each PR, since the
+// retry recomputes the whole state of PR instead of replaying the failed
+// actions. The queue is kept in memory like the recheckScheduler.
+type retryQueue struct {
+	lock        sync.Mutex
+	pending     map[string]struct{}
+	maxAttempts int
+}
+
+func newRetryQueue(maxAttempts int) *retryQueue {
+	if maxAttempts <= 0 {
+		return nil
+	}
+
+	return &retryQueue{
+		pending:     map[string]struct{}{},
+		maxAttempts: maxAttempts,
+	}
+}
+
+// add schedules f to be retried. It does nothing if there is a pending retry of key.
+func (q *retryQueue) add(key string, f func() error, log *logrus.Entry) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if _, ok := q.pending[key]; ok {
+		return
+	}
+
+	q.pending[key] = struct{}{}
+	q.schedule(key, 1, f, log)
+}
+
+func (q *retryQueue) schedule(key string, attempt int, f func() error, log *logrus.Entry) {
+	d := retryDelay(attempt)
+
+	log.Infof("retry %s after %s, attempt %d", key, d, attempt)
+
+	time.AfterFunc(d, func() {
+		err := f()
+		if err != nil && attempt < q.maxAttempts && isTransientError(err) {
+			log.WithError(err).Warnf("retry %s", key)
+			q.schedule(key, attempt+1, f, log)
+			return
+		}
+
+		if err != nil {
+			log.WithError(err).Errorf("give up retrying %s after %d attempts", key, attempt)
+		}
+
+		q.lock.Lock()
+		delete(q.pending, key)
+		q.lock.Unlock()
+	})
+}
+
+// retryDelay returns the exponential backoff of attempt with full jitter.
+func retryDelay(attempt int) time.Duration {
+	d := retryMaxDelay
+	if attempt < 16 {
+		if v := retryBaseDelay << uint(attempt-1); v < d {
+			d = v
+		}
+	}
+
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// transientErrors is the messages of errors which may disappear when retrying,
+// such as the network errors and the 5xx or 429 status of gitee api.
+var transientErrors = []string{
+	"timeout", "connection reset", "connection refused", "EOF",
+	"Too Many Requests", "Internal Server Error", "Bad Gateway",
+	"Service Unavailable", "Gateway Timeout",
+}
+
+// isTransientError returns whether the error may disappear when retrying.
+// The errors of gitee client are only strings, so they are matched by the
+// messages.
+func isTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var ne net.Error
+	if errors.As(err, &ne) {
+		return true
+	}
+
+	s := err.Error()
+	for _, item := range transientErrors {
+		if strings.Contains(s, item) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// retryPR retries the PR whose handling failed by the transient error.
+// The retry only makes the labels and Review Guide consistent with the
+// review state, and does not replay the replies of commands.
+func (bot *robot) retryPR(pr iPRInfo, cfg *botConfig, err error, log *logrus.Entry) {
+	if bot.retry == nil || !isTransientError(err) {
+		return
+	}
+
+	org, repo := pr.getOrgAndRepo()
+	number := pr.getNumber()
+
+	key := prKey(pr)
+	bot.retry.add(key, func() error {
+		return bot.withPRLock(key, func() error {
+			return bot.syncPR(org, repo, number, cfg, log)
+		})
+	}, log)
+}
+
+// syncPR fetches the latest PR and makes its labels and Review Guide
+// consistent with the review state computed from the comments.
+func (bot *robot) syncPR(org, repo string, number int32, cfg *botConfig, log *logrus.Entry) error {
+	pr, err := bot.getPRInfo(org, repo, number)
+	if err != nil {
+		return err
+	}
+
+	if !pr.isOpen() {
+		return nil
+	}
+
+	ctx, err := bot.genReviewContext(pr, pr.getAssignees(), cfg)
+	if err != nil {
+		return err
+	}
+
+	rs, rr := ctx.info.doStats(ctx.stats, bot.botName)
+	if !rs.hasNoCommand() {
+		bot.scheduleRecheck(pr, cfg, rr, log)
+
+		pa := bot.newPostAction(ctx, cfg, "", log)
+
+		return pa.do(ctx.info.reviewGuides(bot.botName), "", rs, rr, bot.botName)
+	}
+
+	// There is no review since the latest code update, so only the
+	// can-review label is kept if the PR is ready to review.
+	ready := !cfg.Draft.isDraft(pr) &&
+		(pr.hasLabel(labelCanReview) || cfg.CI.NoCI || pr.hasLabel(cfg.CI.LabelForCIPassed)) &&
+		!bot.isBlockedByOwnersReport(ctx.info.comments, cfg)
+
+	var toKeep []string
+	if ready {
+		toKeep = append(toKeep, labelCanReview)
+	}
+
+	// The labels are removed silently, since the comment of new changes
+	// has been written when handling the event of push if it is needed.
+	if _, err := bot.removeReviewLabels(pr, cfg, toKeep, newAuditState(&rs, &rr), log); err != nil {
+		return err
+	}
+
+	if ready {
+		return bot.addReviewNotification(pr, cfg, log)
+	}
+
+	return nil
+}
@@ -8,7 +8,7 @@ import (
 	"github.com/opensourceways/repo-owners-cache/repoowners"
 )
 
-func (bot *robot) genRepoOwner(org, repo, branch string) (repoowners.RepoOwner, error) {
+func (bot *robot) genRepoOwner(org, repo, branch string, cfg *botConfig) (repoowners.RepoOwner, error) {
 	owners, err := repoowners.NewRepoOwners(
 		repoowners.RepoBranch{
 			Platform: "gitee",
@@ -22,14 +22,16 @@ func (bot *robot) genRepoOwner(org, repo, branch string) (repoowners.RepoOwner,
 		return nil, err
 	}
 	if owners != nil {
-		return owners, nil
+		owners = newOwnersFileOwner(newTeamOwner(owners, cfg.teams))
+	} else {
+		cs, err := bot.client.listCollaborators(org, repo)
+		if err != nil {
+			return nil, err
+		}
+		owners = repoowners.RepoMemberAsOwners(cs)
 	}
 
-	cs, err := bot.client.listCollaborators(org, repo)
-	if err != nil {
-		return nil, err
-	}
-	return repoowners.RepoMemberAsOwners(cs), nil
+	return owners, nil
 }
 
 func (bot *robot) genPullRequest(prInfo iPRInfo, assignees []string, owner repoowners.RepoOwner) (pullRequest, error) {
@@ -50,7 +52,10 @@ func (bot *robot) getReviewInfo(info iPRInfo) (ri reviewInfo, err error) {
 		return
 	}
 
-	ri.t, err = bot.client.getPRCodeUpdateTime(org, repo, info.getHeadSHA())
+	if ri.t, err = bot.client.getPRCodeUpdateTime(org, repo, info.getHeadSHA()); err == nil {
+		bot.adjustReviewStartTime(&ri)
+	}
+
 	return
 }
 
@@ -1,5 +1,7 @@
 package main
 
+import "fmt"
+
 type reviewConfig struct {
 	// AllowSelfApprove is the tag which indicate if the author
 	// can appove his/her own pull-request.
@@ -20,9 +22,80 @@ type reviewConfig struct {
 	// TotalNumberOfReviewers is the min number of reviewers who commented
 	// /lgtm at same time to add lgtm label
 	TotalNumberOfReviewers int `json:"total_number_of_reviewers"`
+
+	// RankByHistory enables to suggest the reviewers who touched the
+	// changed files more recently and more often in the commit history
+	// of target branch. The reviewers are picked randomly if it is not set.
+	RankByHistory *historyRankConfig `json:"rank_by_history,omitempty"`
+
+	// RequiredApprovals is the rules which require the specific people
+	// or teams to approve the certain files besides the approvers in OWNERS.
+	RequiredApprovals []requiredApprovalConfig `json:"required_approvals,omitempty"`
+
+	// MinApproverAffiliations is the min number of different organizations
+	// which the approvers come from. The organizations of people are loaded
+	// from the affiliation file, so the robot must be started with it if
+	// this is bigger than 1.
+	MinApproverAffiliations int `json:"min_approver_affiliations,omitempty"`
+
+	// ApproverWeights is the weights of approvers when counting them for
+	// the TotalNumberOfApprovers. The default weight is 1.
+	ApproverWeights map[string]int `json:"approver_weights,omitempty"`
+
+	// IgnoredAccounts is the bot accounts whose review commands are ignored.
+	IgnoredAccounts []string `json:"ignored_accounts,omitempty"`
+
+	// RequireCollaborator specifies whether to ignore the review commands
+	// of the people who are not the collaborators of repo.
+	RequireCollaborator bool `json:"require_collaborator,omitempty"`
+
+	// Rejection specifies how to resolve the /reject of approvers.
+	Rejection rejectionConfig `json:"rejection,omitempty"`
+
+	// RequireReasonToDisagree specifies whether /reject and /lbtm must
+	// be followed by a reason, such as `/reject the design is not agreed`.
+	RequireReasonToDisagree bool `json:"require_reason_to_disagree,omitempty"`
+
+	// MinReviewHours is the min hours since the can-review label was added
+	// before the approved label can be added.
+	MinReviewHours int `json:"min_review_hours,omitempty"`
+
+	// MinReviewHoursOfPaths specifies the min review hours for the certain files.
+	// The biggest one applies if several ones match the files of PR.
+	MinReviewHoursOfPaths []soakPathConfig `json:"min_review_hours_of_paths,omitempty"`
 }
 
 func (r reviewConfig) validate() error {
+	for i := range r.RequiredApprovals {
+		if err := r.RequiredApprovals[i].validate(); err != nil {
+			return err
+		}
+	}
+
+	if r.MinApproverAffiliations < 0 {
+		return fmt.Errorf("min_approver_affiliations must not be negative")
+	}
+
+	for k, v := range r.ApproverWeights {
+		if v < 0 {
+			return fmt.Errorf("the weight of approver %s must not be negative", k)
+		}
+	}
+
+	if err := r.Rejection.validate(); err != nil {
+		return err
+	}
+
+	if r.MinReviewHours < 0 {
+		return fmt.Errorf("min_review_hours must not be negative")
+	}
+
+	for i := range r.MinReviewHoursOfPaths {
+		if err := r.MinReviewHoursOfPaths[i].validate(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -42,4 +115,19 @@ func (r *reviewConfig) setDefault() {
 	if r.TotalNumberOfReviewers == 0 {
 		r.TotalNumberOfReviewers = 1
 	}
+
+	r.RankByHistory.setDefault()
+	r.Rejection.setDefault()
+
+	if len(r.ApproverWeights) > 0 {
+		v := make(map[string]int, len(r.ApproverWeights))
+		for k, w := range r.ApproverWeights {
+			v[normalizeLogin(k)] = w
+		}
+		r.ApproverWeights = v
+	}
+
+	for i := range r.RequiredApprovals {
+		r.RequiredApprovals[i].setDefault()
+	}
 }
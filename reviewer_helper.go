@@ -10,8 +10,8 @@ import (
 )
 
 func suggestReviewers(
-	c ghclient, owner repoowners.RepoOwner,
-	pr iPRInfo, reviewerCount int, log *logrus.Entry,
+	c ghclient, owner repoowners.RepoOwner, pr iPRInfo,
+	reviewerCount int, ranker *expertiseRanker, log *logrus.Entry,
 ) ([]string, error) {
 	org, repo := pr.getOrgAndRepo()
 	changes, err := c.getPullRequestChanges(org, repo, pr.getNumber())
@@ -19,9 +19,15 @@ func suggestReviewers(
 		return nil, err
 	}
 
+	// The reviewers are picked randomly if there is no ranker.
+	var pick reviewerPicker
+	if ranker != nil {
+		pick = ranker.picker(org, repo, pr.getTargetBranch(), changes, log)
+	}
+
 	excludedReviewers := sets.NewString(normalizeLogin(pr.getAuthor()))
 
-	reviewers := getReviewers(owner, changes, reviewerCount, excludedReviewers)
+	reviewers := getReviewers(owner, changes, reviewerCount, excludedReviewers, pick)
 	if len(reviewers) < reviewerCount {
 
 		approvers := getReviewers(
@@ -29,9 +35,12 @@ func suggestReviewers(
 			changes,
 			reviewerCount-len(reviewers),
 			excludedReviewers.Insert(reviewers...),
+			pick,
 		)
 		reviewers = append(reviewers, approvers...)
-		sort.Strings(reviewers)
+		if ranker == nil {
+			sort.Strings(reviewers)
+		}
 
 		log.Infof("Added %d approvers as reviewers.", len(approvers))
 	}
@@ -46,7 +55,10 @@ func suggestReviewers(
 	return reviewers, nil
 }
 
-func getReviewers(rc reviewersClient, files []string, minReviewers int, excludedReviewers sets.String) []string {
+func getReviewers(
+	rc reviewersClient, files []string, minReviewers int,
+	excludedReviewers sets.String, pick reviewerPicker,
+) []string {
 	leafReviewers := sets.NewString()
 	for _, filename := range files {
 		v := rc.LeafReviewers(filename).Difference(excludedReviewers)
@@ -61,6 +73,10 @@ func getReviewers(rc reviewersClient, files []string, minReviewers int, excluded
 	}
 
 	if n > minReviewers {
+		if pick != nil {
+			return pick(leafReviewers, minReviewers)
+		}
+
 		r := findReviewer(leafReviewers, minReviewers)
 		sort.Strings(r)
 		return r
@@ -75,6 +91,10 @@ func getReviewers(rc reviewersClient, files []string, minReviewers int, excluded
 	}
 
 	n = minReviewers - n
+	if pick != nil {
+		return append(pick(leafReviewers, 0), pick(fileReviewers, n)...)
+	}
+
 	if fileReviewers.Len() <= n {
 		return leafReviewers.Union(fileReviewers).List()
 	}
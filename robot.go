@@ -2,21 +2,49 @@ package main
 
 import (
 	"errors"
+	"sync/atomic"
+	"time"
 
 	"github.com/opensourceways/community-robot-lib/config"
+	"github.com/opensourceways/community-robot-lib/giteeclient"
 	"github.com/opensourceways/community-robot-lib/robot-gitee-framework"
 	sdk "github.com/opensourceways/go-gitee/gitee"
 	"github.com/opensourceways/repo-owners-cache/grpc/client"
 	"github.com/sirupsen/logrus"
+
+	"github.com/opensourceways/robot-gitee-review-trigger/audit"
+	"github.com/opensourceways/robot-gitee-review-trigger/mq"
+	"github.com/opensourceways/robot-gitee-review-trigger/notify"
 )
 
 const botName = "review-trigger"
 
-func newRobot(cli iClient, cacheCli *client.Client, botName string) *robot {
+func newRobot(
+	cli iClient, cacheCli *client.Client, history iCommitHistory,
+	ooo *oooStore, queue *mergeQueue, auditStore audit.Store, affiliations affiliations,
+	mailer *notify.Mailer, publisher mq.Publisher, publishTopic string,
+	retryAttempts int, botName string,
+) *robot {
 	return &robot{
 		client:   ghclient{cli},
 		botName:  botName,
 		cacheCli: cacheCli,
+		history:  history,
+		ooo:      ooo,
+		audit:    auditStore,
+		recheck:  newRecheckScheduler(),
+		retry:    newRetryQueue(retryAttempts),
+		events:   newEventCache(eventTTL),
+		prLocks:  newPRLocks(),
+		drafts:   newDraftStates(),
+
+		readyEvents:   newEventCache(readyEventTTL),
+		mergeQueue:    queue,
+		collaborators: newCollaboratorCache(collaboratorsTTL),
+		affiliations:  affiliations,
+		mailer:        mailer,
+		publisher:     publisher,
+		publishTopic:  publishTopic,
 	}
 }
 
@@ -33,12 +61,46 @@ type iClient interface {
 	UpdatePRComment(org, repo string, commentID int32, comment string) error
 	GetPullRequestChanges(org, repo string, number int32) ([]sdk.PullRequestFiles, error)
 	ListCollaborators(org, repo string) ([]sdk.ProjectMember, error)
+	GetGiteePullRequest(org, repo string, number int32) (sdk.PullRequest, error)
+	GetPullRequests(org, repo string, opts giteeclient.ListPullRequestOpt) ([]sdk.PullRequest, error)
+	GetPathContent(org, repo, path, ref string) (sdk.Content, error)
+	ListPROperationLogs(org, repo string, number int32) ([]sdk.OperateLog, error)
+	GetRepos(org string) ([]sdk.Project, error)
+	MergePR(owner, repo string, number int32, opt sdk.PullRequestMergePutParam) error
+	ListRepoCommits(org, repo, branch, path string, since time.Time) ([]sdk.RepoCommit, error)
 }
 
 type robot struct {
 	botName  string
 	client   ghclient
 	cacheCli *client.Client
+	history  iCommitHistory
+	ooo      *oooStore
+	audit    audit.Store
+	recheck  *recheckScheduler
+	retry    *retryQueue
+	events   *eventCache
+	prLocks  *prLocks
+	drafts   *draftStates
+
+	// readyEvents is the ready_for_review events sent recently.
+	readyEvents *eventCache
+
+	mergeQueue    *mergeQueue
+	collaborators *collaboratorCache
+	affiliations  affiliations
+
+	// mailer sends the notifications by email. It is nil if the smtp server is not set.
+	mailer *notify.Mailer
+
+	// publisher publishes the review state changes to publishTopic.
+	// It is nil if the message queue is not set.
+	publisher    mq.Publisher
+	publishTopic string
+
+	// latestConfig is the config loaded at startup or received by the
+	// latest event.
+	latestConfig atomic.Value
 }
 
 func (bot *robot) NewConfig() config.Config {
@@ -47,11 +109,25 @@ func (bot *robot) NewConfig() config.Config {
 
 func (bot *robot) getConfig(cfg config.Config) (*configuration, error) {
 	if c, ok := cfg.(*configuration); ok {
+		if err := bot.affiliations.check(c); err != nil {
+			return nil, err
+		}
+
+		bot.latestConfig.Store(c)
 		return c, nil
 	}
 	return nil, errors.New("can't convert to configuration")
 }
 
+func (bot *robot) setConfig(c *configuration) {
+	bot.latestConfig.Store(c)
+}
+
+func (bot *robot) currentConfig() *configuration {
+	c, _ := bot.latestConfig.Load().(*configuration)
+	return c
+}
+
 func (bot *robot) RegisterEventHandler(f framework.HandlerRegitster) {
 	f.RegisterPullRequestHandler(bot.handlePREvent)
 	f.RegisterNoteEventHandler(bot.handleNoteEvent)
@@ -68,7 +144,22 @@ func (bot *robot) handlePREvent(e *sdk.PullRequestEvent, c config.Config, log *l
 		return nil
 	}
 
-	return bot.processPREvent(e, bc, log)
+	key := prEventKey(e)
+	if !bot.events.begin(key) {
+		log.Info("skip the duplicate event")
+		return nil
+	}
+
+	err = bot.withPRLock(prKey(prInfoOnPREvent{e}), func() error {
+		return bot.processPREvent(e, bc, log)
+	})
+	bot.events.end(key, err == nil)
+
+	if err != nil {
+		bot.retryPR(prInfoOnPREvent{e}, bc, err, log)
+	}
+
+	return err
 }
 
 func (bot *robot) handleNoteEvent(e *sdk.NoteEvent, c config.Config, log *logrus.Entry) error {
@@ -82,5 +173,30 @@ func (bot *robot) handleNoteEvent(e *sdk.NoteEvent, c config.Config, log *logrus
 		return nil
 	}
 
-	return bot.processNoteEvent(e, bc, log)
+	key := ""
+	if e.GetComment() != nil {
+		key = noteEventKey(e)
+		if !bot.events.begin(key) {
+			log.Info("skip the duplicate event")
+			return nil
+		}
+	}
+
+	if e.IsPullRequest() {
+		err = bot.withPRLock(prKey(prInfoOnNoteEvent{e}), func() error {
+			return bot.processNoteEvent(e, bc, log)
+		})
+	} else {
+		err = bot.processNoteEvent(e, bc, log)
+	}
+
+	if key != "" {
+		bot.events.end(key, err == nil)
+	}
+
+	if err != nil && e.IsPullRequest() {
+		bot.retryPR(prInfoOnNoteEvent{e}, bc, err, log)
+	}
+
+	return err
 }
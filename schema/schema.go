@@ -0,0 +1,135 @@
+// Package schema generates the JSON Schema of a config struct from its
+// json tags, so that the editors can validate the config file.
+package schema
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+const draft = "http://json-schema.org/draft-07/schema#"
+
+// Schema is the subset of JSON Schema used to describe the config.
+type Schema struct {
+	Version              string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+}
+
+// Generate returns the schema of v which should be a struct or a pointer to it.
+// The field is required if it has the tag of `required:"true"`, which is
+// the same rule as the config loader.
+func Generate(title string, v interface{}) *Schema {
+	s := of(reflect.TypeOf(v))
+	s.Version = draft
+	s.Title = title
+
+	return s
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func of(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+
+	case reflect.String:
+		return &Schema{Type: "string"}
+
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: of(t.Elem())}
+
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: of(t.Elem())}
+
+	case reflect.Struct:
+		s := &Schema{
+			Type:                 "object",
+			Properties:           map[string]*Schema{},
+			AdditionalProperties: false,
+		}
+		addFields(s, t)
+
+		return s
+	}
+
+	return &Schema{}
+}
+
+func addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		name, ok := fieldName(f)
+		if !ok {
+			continue
+		}
+
+		if name == "" {
+			// The embedded struct without json name is inlined.
+			ft := f.Type
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+
+			if ft.Kind() == reflect.Struct {
+				addFields(s, ft)
+			}
+
+			continue
+		}
+
+		s.Properties[name] = of(f.Type)
+
+		if f.Tag.Get("required") == "true" {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// fieldName returns the json name of field. It returns false if the field
+// is not encoded, and empty name if it is an embedded field to be inlined.
+func fieldName(f reflect.StructField) (string, bool) {
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+
+	name := strings.Split(tag, ",")[0]
+
+	if f.Anonymous && name == "" {
+		return "", true
+	}
+
+	if f.PkgPath != "" {
+		return "", false
+	}
+
+	if name == "" {
+		name = f.Name
+	}
+
+	return name, true
+}
@@ -0,0 +1,66 @@
+package schema
+
+import (
+	"testing"
+	"time"
+)
+
+type embedded struct {
+	Repos []string `json:"repos" required:"true"`
+}
+
+type item struct {
+	embedded
+
+	Name    string            `json:"name" required:"true"`
+	Count   *int              `json:"count,omitempty"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	At      time.Time         `json:"at,omitempty"`
+	Ignored string            `json:"-"`
+
+	hidden string
+}
+
+type config struct {
+	Items []item `json:"items,omitempty"`
+}
+
+func TestGenerate(t *testing.T) {
+	s := Generate("test", &config{})
+
+	if s.Version != draft || s.Title != "test" || s.Type != "object" {
+		t.Fatalf("unexpected root: %+v", s)
+	}
+
+	items := s.Properties["items"]
+	if items == nil || items.Type != "array" || items.Items == nil {
+		t.Fatalf("unexpected items: %+v", items)
+	}
+
+	it := items.Items
+	expect := map[string]string{
+		"repos":  "array",
+		"name":   "string",
+		"count":  "integer",
+		"labels": "object",
+		"at":     "string",
+	}
+
+	if len(it.Properties) != len(expect) {
+		t.Errorf("expect %d properties, but got %d", len(expect), len(it.Properties))
+	}
+
+	for k, v := range expect {
+		if p := it.Properties[k]; p == nil || p.Type != v {
+			t.Errorf("expect %s to be %s, but got %+v", k, v, p)
+		}
+	}
+
+	if len(it.Required) != 2 || it.Required[0] != "repos" || it.Required[1] != "name" {
+		t.Errorf("unexpected required: %v", it.Required)
+	}
+
+	if it.Properties["at"].Format != "date-time" {
+		t.Errorf("expect the format of time to be date-time")
+	}
+}
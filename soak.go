@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type soakPathConfig struct {
+	// Paths is the patterns of files which the MinReviewHours applies to.
+	Paths pathPatterns `json:"paths" required:"true"`
+
+	// MinReviewHours is the min hours of review for the files.
+	MinReviewHours int `json:"min_review_hours" required:"true"`
+}
+
+func (c soakPathConfig) validate() error {
+	if len(c.Paths) == 0 {
+		return fmt.Errorf("missing paths of min_review_hours_of_paths")
+	}
+
+	if c.MinReviewHours <= 0 {
+		return fmt.Errorf("min_review_hours of paths must be bigger than 0")
+	}
+
+	return c.Paths.validate()
+}
+
+// minReviewHours returns the biggest one of min review hours
+// which apply to the files.
+func (r reviewConfig) minReviewHours(files []string) int {
+	h := r.MinReviewHours
+	for i := range r.MinReviewHoursOfPaths {
+		item := &r.MinReviewHoursOfPaths[i]
+		if item.MinReviewHours > h && item.Paths.matchAny(files) {
+			h = item.MinReviewHours
+		}
+	}
+
+	return h
+}
+
+// recheckScheduler recomputes the review state of PR at the given time.
+// The schedules are kept in memory, so they will be lost after the robot
+// restarts and the PR will be rechecked at the next event of it.
+type recheckScheduler struct {
+	lock   sync.Mutex
+	timers map[string]*time.Timer
+}
+
+func newRecheckScheduler() *recheckScheduler {
+	return &recheckScheduler{timers: map[string]*time.Timer{}}
+}
+
+func (s *recheckScheduler) schedule(key string, t time.Time, f func()) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if v, ok := s.timers[key]; ok {
+		v.Stop()
+	}
+
+	s.timers[key] = time.AfterFunc(time.Until(t), func() {
+		s.lock.Lock()
+		delete(s.timers, key)
+		s.lock.Unlock()
+
+		f()
+	})
+}
+
+func (s *recheckScheduler) cancel(key string) {
+	if s == nil {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if v, ok := s.timers[key]; ok {
+		v.Stop()
+		delete(s.timers, key)
+	}
+}
+
+func (bot *robot) scheduleRecheck(pr iPRInfo, cfg *botConfig, r reviewResult, log *logrus.Entry) {
+	if bot.recheck == nil || r.waitUntil.IsZero() {
+		return
+	}
+
+	org, repo := pr.getOrgAndRepo()
+	number := pr.getNumber()
+
+	// recheck a little later to avoid the deviation of time
+	t := r.waitUntil.Add(time.Minute)
+
+	key := prKey(pr)
+
+	log.Infof("recheck the review state of %s at %s", key, t.Format(time.RFC3339))
+
+	bot.recheck.schedule(key, t, func() {
+		err := bot.withPRLock(key, func() error {
+			return bot.recheckPR(org, repo, number, cfg, log)
+		})
+		if err != nil {
+			log.WithError(err).Error("recheck the review state")
+		}
+	})
+}
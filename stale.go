@@ -0,0 +1,329 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/opensourceways/community-robot-lib/giteeclient"
+	"github.com/opensourceways/repo-owners-cache/repoowners"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/opensourceways/robot-gitee-review-trigger/notify"
+)
+
+const (
+	staleReminderTitle   = "### Review Reminder"
+	staleEscalationTitle = "### Review Escalation"
+
+	notificationEscalationPart = "\n#### Escalation:\n"
+)
+
+type staleConfig struct {
+	// RemindDays is the days without activity after which the suggested
+	// reviewers or approvers will be reminded again. The reminder will be
+	// repeated every RemindDays until there is a new activity.
+	RemindDays int `json:"remind_days" required:"true"`
+
+	// EscalateDays is the days without activity after which the PR will
+	// be escalated to the approvers of parent directory or root. The
+	// escalation will be repeated every EscalateDays until there is a new
+	// activity. It is not escalated if it is 0.
+	EscalateDays int `json:"escalate_days,omitempty"`
+}
+
+func (c *staleConfig) validate() error {
+	if c == nil {
+		return nil
+	}
+
+	if c.RemindDays <= 0 {
+		return fmt.Errorf("remind_days must be bigger than 0")
+	}
+
+	if c.EscalateDays != 0 && c.EscalateDays <= c.RemindDays {
+		return fmt.Errorf("escalate_days must be bigger than remind_days")
+	}
+
+	return nil
+}
+
+// runStaleChecker checks the stale PRs periodically. The repos are from
+// the current config, and all the repos of org are checked if the config
+// is specified for the org.
+func (bot *robot) runStaleChecker(interval time.Duration, stop <-chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+			bot.checkStalePRs()
+		}
+	}
+}
+
+func (bot *robot) checkStalePRs() {
+	cfg := bot.currentConfig()
+	if cfg == nil {
+		return
+	}
+
+	log := logrus.WithField("component", "stale-checker")
+
+	for _, orgRepo := range bot.reposOfStaleCheck(cfg, log).List() {
+		v := strings.Split(orgRepo, "/")
+		org, repo := v[0], v[1]
+
+		// The config of repo may be the one which does not check stale PRs.
+		bc := cfg.configFor(org, repo)
+		if bc == nil || bc.Stale == nil {
+			continue
+		}
+
+		if err := bot.checkStalePRsOfRepo(org, repo, bc, log); err != nil {
+			log.WithError(err).Errorf("check stale PRs of %s", orgRepo)
+		}
+	}
+}
+
+// reposOfStaleCheck returns the repos of config items which check the
+// stale PRs. The repos of org are listed if the item is for the org.
+func (bot *robot) reposOfStaleCheck(cfg *configuration, log *logrus.Entry) sets.String {
+	r := sets.NewString()
+
+	for i := range cfg.items {
+		item := &cfg.items[i]
+		if item.Stale == nil {
+			continue
+		}
+
+		for _, orgRepo := range item.Repos {
+			if strings.Contains(orgRepo, "/") {
+				r.Insert(orgRepo)
+				continue
+			}
+
+			repos, err := bot.client.GetRepos(orgRepo)
+			if err != nil {
+				log.WithError(err).Errorf("list repos of %s", orgRepo)
+				continue
+			}
+
+			for j := range repos {
+				r.Insert(orgRepo + "/" + repos[j].Path)
+			}
+		}
+	}
+
+	return r
+}
+
+func (bot *robot) checkStalePRsOfRepo(org, repo string, cfg *botConfig, log *logrus.Entry) error {
+	prs, err := bot.client.GetPullRequests(org, repo, giteeclient.ListPullRequestOpt{
+		State:  "open",
+		Labels: []string{labelCanReview},
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := range prs {
+		pr := newPRInfoOnAPI(org, repo, &prs[i])
+
+		if err := bot.checkStalePR(pr, cfg, log); err != nil {
+			log.WithError(err).Errorf("check stale PR %s", prKey(pr))
+		}
+	}
+
+	return nil
+}
+
+func (bot *robot) checkStalePR(pr prInfoOnAPI, cfg *botConfig, log *logrus.Entry) error {
+	if !pr.hasLabel(labelCanReview) || pr.hasLabel(labelRequestChange) ||
+		(pr.hasLabel(labelLGTM) && pr.hasLabel(labelApproved)) {
+		return nil
+	}
+
+	ctx, err := bot.genReviewContext(pr, pr.getAssignees(), cfg)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	day := 24 * time.Hour
+	idle := now.Sub(lastActivity(ctx.info, bot.botName))
+
+	reminders := giteeclient.FindBotComment(ctx.info.comments, bot.botName, isStaleComment)
+	var lastReminder, lastEscalation time.Time
+	for i := range reminders {
+		t := reminders[i].CreatedAt
+		if strings.HasPrefix(reminders[i].Body, staleEscalationTitle) && t.After(lastEscalation) {
+			lastEscalation = t
+		}
+		if t.After(lastReminder) {
+			lastReminder = t
+		}
+	}
+
+	esc := time.Duration(cfg.Stale.EscalateDays) * day
+	if esc > 0 && idle >= esc && now.Sub(lastEscalation) >= esc {
+		return bot.escalate(ctx, cfg, idle, log)
+	}
+
+	remind := time.Duration(cfg.Stale.RemindDays) * day
+	if idle >= remind && now.Sub(lastReminder) >= remind {
+		return bot.remind(ctx, cfg, idle, log)
+	}
+
+	return nil
+}
+
+// lastActivity returns the latest time of code update or comment
+// which is not written by the robot.
+func lastActivity(info reviewInfo, botName string) time.Time {
+	t := info.t
+	for i := range info.comments {
+		c := &info.comments[i]
+		if c.User == nil || c.User.Login == botName {
+			continue
+		}
+
+		if ut, err := time.Parse(time.RFC3339, c.UpdatedAt); err == nil && ut.After(t) {
+			t = ut
+		}
+	}
+
+	return t
+}
+
+func (bot *robot) remind(ctx reviewContext, cfg *botConfig, idle time.Duration, log *logrus.Entry) error {
+	rs, _ := ctx.info.doStats(ctx.stats, bot.botName)
+	pa := bot.newPostAction(ctx, cfg, "", log)
+
+	var people []string
+	var action string
+	if ctx.pr.info.hasLabel(labelLGTM) {
+		people = pa.suggestApprovers(rs.agreedApprovers)
+		action = "/approve"
+	} else {
+		people = pa.suggestReviewers()
+		action = "/lgtm"
+	}
+
+	if len(people) == 0 {
+		return nil
+	}
+
+	s := fmt.Sprintf(
+		"%s\n\nThis PR has had no activity for **%d** days. %s, could you take a look and comment `%s` if it is fine? Thanks!",
+		staleReminderTitle, int(idle.Hours()/24), toMentionList(people), action,
+	)
+
+	org, repo := ctx.pr.info.getOrgAndRepo()
+
+	if err := bot.client.CreatePRComment(org, repo, ctx.pr.info.getNumber(), s); err != nil {
+		return err
+	}
+
+	bot.newNotifier(ctx.pr.info, "", cfg, log).notify(
+		notify.EventStale,
+		fmt.Sprintf("It has had no activity for %d days, reminded %s.", int(idle.Hours()/24), strings.Join(people, ", ")),
+	)
+
+	return nil
+}
+
+func (bot *robot) escalate(ctx reviewContext, cfg *botConfig, idle time.Duration, log *logrus.Entry) error {
+	people := escalationApprovers(ctx.owner, ctx.pr).Delete(normalizeLogin(ctx.pr.info.getAuthor()))
+	if people.Len() == 0 {
+		log.Warnf("no approvers to escalate %s to", prKey(ctx.pr.info))
+		return nil
+	}
+
+	v := people.List()
+
+	s := fmt.Sprintf(
+		"%s\n\nThis PR has had no activity for **%d** days, so it is escalated to the approvers of parent directories( %s ). Please help to move it forward.",
+		staleEscalationTitle, int(idle.Hours()/24), toMentionList(v),
+	)
+
+	info := ctx.pr.info
+	org, repo := info.getOrgAndRepo()
+
+	if err := bot.client.CreatePRComment(org, repo, info.getNumber(), s); err != nil {
+		return err
+	}
+
+	bot.newNotifier(info, "", cfg, log).notify(
+		notify.EventStale,
+		fmt.Sprintf("It has had no activity for %d days, escalated to %s.", int(idle.Hours()/24), strings.Join(v, ", ")),
+	)
+
+	// record the escalation in the Review Guide
+	guides := ctx.info.reviewGuides(bot.botName)
+	if n := len(guides); n > 0 {
+		giteeclient.SortBotComments(guides)
+
+		g := &guides[n-1]
+
+		e := fmt.Sprintf(
+			"It was escalated to %s at %s.",
+			toReviewerList(v), time.Now().Format(timeLayoutOfGuide),
+		)
+		if strings.Contains(g.Body, notificationEscalationPart) {
+			e = "\n" + e
+		} else {
+			e = notificationEscalationPart + e
+		}
+
+		return bot.client.UpdatePRComment(org, repo, g.CommentID, g.Body+e)
+	}
+
+	return nil
+}
+
+// escalationApprovers returns the approvers of OWNERS file which is the
+// parent of the closest one for each file, or the root approvers.
+func escalationApprovers(owner repoowners.RepoOwner, pr *pullRequest) sets.String {
+	r := sets.NewString()
+	for _, f := range pr.files {
+		dir := owner.FindApproverOwnersForFile(f)
+		if dir == "" || dir == "." {
+			r = r.Union(owner.TopLevelApprovers())
+			continue
+		}
+
+		r = r.Union(owner.LeafApprovers(parentDir(dir)))
+	}
+
+	return r
+}
+
+func toMentionList(v []string) string {
+	r := make([]string, 0, len(v))
+	for _, item := range v {
+		r = append(r, "@"+item)
+	}
+	return strings.Join(r, " ")
+}
+
+func isStaleComment(c string) bool {
+	return strings.HasPrefix(c, staleReminderTitle) || strings.HasPrefix(c, staleEscalationTitle)
+}
+
+// keepEscalation keeps the escalation record of old Review Guide in the new one.
+func keepEscalation(desc, oldTips string) string {
+	if desc == "" || strings.Contains(desc, notificationEscalationPart) {
+		return desc
+	}
+
+	if i := strings.Index(oldTips, notificationEscalationPart); i >= 0 {
+		return desc + oldTips[i:]
+	}
+
+	return desc
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/opensourceways/robot-gitee-review-trigger/mq"
+)
+
+const eventReviewStateChanged = "review.state_changed"
+
+type reviewStateChanged struct {
+	Type    string    `json:"type"`
+	Time    time.Time `json:"time"`
+	Org     string    `json:"org"`
+	Repo    string    `json:"repo"`
+	Number  int32     `json:"number"`
+	HeadSHA string    `json:"head_sha"`
+	Actor   string    `json:"actor,omitempty"`
+
+	LabelsAdded   []string `json:"labels_added,omitempty"`
+	LabelsRemoved []string `json:"labels_removed,omitempty"`
+
+	// State is the review summary and result which is same as the audit.
+	State json.RawMessage `json:"state,omitempty"`
+}
+
+const publishBufferSize = 1000
+
+// asyncPublisher publishes the messages one by one in background, so the
+// handling of PR is not blocked by the message queue, and the messages are
+// kept in order.
+type asyncPublisher struct {
+	publisher mq.Publisher
+	messages  chan mq.Message
+	done      chan struct{}
+}
+
+func newAsyncPublisher(p mq.Publisher) *asyncPublisher {
+	ap := &asyncPublisher{
+		publisher: p,
+		messages:  make(chan mq.Message, publishBufferSize),
+		done:      make(chan struct{}),
+	}
+
+	go ap.run()
+
+	return ap
+}
+
+// Publish fails if there are too many messages not published yet.
+func (p *asyncPublisher) Publish(topic, key string, value []byte) error {
+	select {
+	case p.messages <- mq.Message{Topic: topic, Key: key, Value: value}:
+		return nil
+	default:
+		return fmt.Errorf("too many messages to publish")
+	}
+}
+
+func (p *asyncPublisher) run() {
+	defer close(p.done)
+
+	for m := range p.messages {
+		if err := p.publisher.Publish(m.Topic, m.Key, m.Value); err != nil {
+			logrus.WithError(err).Errorf("publish the review state of %s", m.Key)
+		}
+	}
+}
+
+// Close publishes the rest messages before closing the message queue.
+func (p *asyncPublisher) Close() error {
+	close(p.messages)
+	<-p.done
+
+	return p.publisher.Close()
+}
+
+// statePublisher publishes the review state changes of a PR.
+// All its methods can be called on nil which means publishing is disabled.
+type statePublisher struct {
+	publisher mq.Publisher
+	topic     string
+	pr        iPRInfo
+	actor     string
+	log       *logrus.Entry
+}
+
+func (bot *robot) newStatePublisher(pr iPRInfo, actor string, log *logrus.Entry) *statePublisher {
+	if bot.publisher == nil {
+		return nil
+	}
+
+	return &statePublisher{
+		publisher: bot.publisher,
+		topic:     bot.publishTopic,
+		pr:        pr,
+		actor:     actor,
+		log:       log,
+	}
+}
+
+func (p *statePublisher) publish(added, removed []string, state json.RawMessage) {
+	if p == nil || (len(added) == 0 && len(removed) == 0) {
+		return
+	}
+
+	org, repo := p.pr.getOrgAndRepo()
+
+	b, err := json.Marshal(reviewStateChanged{
+		Type:          eventReviewStateChanged,
+		Time:          time.Now(),
+		Org:           org,
+		Repo:          repo,
+		Number:        p.pr.getNumber(),
+		HeadSHA:       p.pr.getHeadSHA(),
+		Actor:         p.actor,
+		LabelsAdded:   added,
+		LabelsRemoved: removed,
+		State:         state,
+	})
+	if err != nil {
+		p.log.WithError(err).Error("marshal the review state")
+		return
+	}
+
+	// The messages of same PR are kept in order by the key.
+	key := fmt.Sprintf("%s/%s/%d", org, repo, p.pr.getNumber())
+
+	if err := p.publisher.Publish(p.topic, key, b); err != nil {
+		p.log.WithError(err).Errorf("publish the review state of %s", key)
+	}
+}
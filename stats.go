@@ -17,7 +17,21 @@ type reviewComment struct {
 type reviewStats struct {
 	pr        *pullRequest
 	cfg       reviewConfig
+	rules     requiredApprovals
 	reviewers sets.String
+
+	// ruleApprovers is the approvers of required approval rules. Their
+	// /approve only counts for the rules.
+	ruleApprovers sets.String
+
+	// canReviewAt is the time when the can-review label was added.
+	canReviewAt time.Time
+
+	rootApprovers sets.String
+
+	commenters commenterPolicy
+
+	affiliations affiliations
 }
 
 func (rs reviewStats) StatReview(
@@ -26,20 +40,75 @@ func (rs reviewStats) StatReview(
 	botName string,
 ) (reviewSummary, reviewResult) {
 
-	commands := rs.filterComments(comments, startTime, botName)
+	commands, ignored := rs.filterComments(comments, startTime, botName)
 	if len(commands) == 0 {
-		return reviewSummary{}, reviewResult{}
+		return reviewSummary{ignoredVoters: ignored}, reviewResult{}
 	}
 
 	r := genReviewSummary(commands)
+	r.ignoredVoters = ignored
+
+	ruleApprovers := rs.splitRuleApprovers(&r)
+
+	unmetRule := func(agreedApprovers []string) string {
+		return joinNonEmpty(
+			notificationLineSpliter,
+			rs.rules.unmet(rs.pr.files, mergeSlices(agreedApprovers, ruleApprovers)),
+			rs.affiliations.unmet(agreedApprovers, rs.cfg.MinApproverAffiliations),
+		)
+	}
+
+	var approvableAt time.Time
+	if h := rs.cfg.minReviewHours(rs.pr.files); h > 0 {
+		// The window starts at the later one of the time when can-review
+		// was added and the start of review which is the latest push,
+		// because a push may keep the can-review label.
+		t := rs.canReviewAt
+		if startTime.After(t) {
+			t = startTime
+		}
+		approvableAt = t.Add(time.Duration(h) * time.Hour)
+	}
+
+	return r, genReviewResult(
+		r, rs.pr.areAllFilesApproved, rs.pr.areAllFilesCommented,
+		unmetRule, approvableAt,
+		newRejectionPolicy(rs.cfg.Rejection, rs.rootApprovers), rs.cfg,
+	)
+}
+
+// splitRuleApprovers removes the approvers who are only the approvers of
+// required approval rules from the summary, and returns them.
+func (rs reviewStats) splitRuleApprovers(r *reviewSummary) []string {
+	if rs.ruleApprovers.Len() == 0 {
+		return nil
+	}
 
-	return r, genReviewResult(r, rs.pr.areAllFilesApproved, rs.pr.areAllFilesCommented, rs.cfg)
+	var v, ruleApprovers []string
+	for _, item := range r.agreedApprovers {
+		if !rs.pr.isApprover(item) && rs.ruleApprovers.Has(item) {
+			ruleApprovers = append(ruleApprovers, item)
+		} else {
+			v = append(v, item)
+		}
+	}
+	r.agreedApprovers = v
+
+	return ruleApprovers
 }
 
-func (rs reviewStats) filterComments(comments []sdk.PullRequestComments, startTime time.Time, botName string) []reviewCommand {
+func (rs reviewStats) filterComments(comments []sdk.PullRequestComments, startTime time.Time, botName string) (
+	[]reviewCommand, map[string]string,
+) {
 	isValidCmd := rs.genCheckCmdFunc()
 
-	newComments := rs.preTreatComments(comments, startTime, botName)
+	// The comments before the latest push are needed to find the sticky /reject.
+	since := startTime
+	if rs.cfg.Rejection.Sticky {
+		since = time.Time{}
+	}
+
+	newComments, ignored := rs.preTreatComments(comments, since, botName)
 	n := len(newComments)
 
 	done := map[string]bool{}
@@ -50,23 +119,40 @@ func (rs reviewStats) filterComments(comments []sdk.PullRequestComments, startTi
 			continue
 		}
 
-		if cmd, _ := getReviewCommand(c.comment, c.author, isValidCmd); cmd != "" {
-			commands = append(commands, reviewCommand{command: cmd, author: c.author})
-			done[c.author] = true
+		cmd, _ := getReviewCommand(c.comment, c.author, isValidCmd)
+		if cmd == "" || isMissingReason(cmd, c.comment, rs.cfg) {
+			continue
+		}
+		done[c.author] = true
+
+		// Only the /reject is kept if it is the latest command of
+		// the approver and commented before the latest push.
+		if c.t.Before(startTime) && cmd != cmdReject {
+			continue
 		}
+
+		commands = append(commands, reviewCommand{
+			command: cmd,
+			author:  c.author,
+			reason:  parseCommandReasons(c.comment)[cmd],
+		})
 	}
 
-	return commands
+	return commands, ignored
 }
 
 // first. filter comments and omit each one
 // which is before the pr code update time
 // or which is not a reviewer
 // or which is commented by bot
+// or which is commented by the ignored commenters, and record the reason
 //
 // second sort the comments by updated time in aesc
-func (rs reviewStats) preTreatComments(comments []sdk.PullRequestComments, startTime time.Time, botName string) []reviewComment {
+func (rs reviewStats) preTreatComments(comments []sdk.PullRequestComments, startTime time.Time, botName string) (
+	[]reviewComment, map[string]string,
+) {
 	r := make([]reviewComment, 0, len(comments))
+	ignored := map[string]string{}
 	for i := range comments {
 		c := &comments[i]
 
@@ -84,6 +170,13 @@ func (rs reviewStats) preTreatComments(comments []sdk.PullRequestComments, start
 			continue
 		}
 
+		if reason := rs.commenters.ignoredReason(author); reason != "" {
+			if len(parseReviewCommand(c.Body)) > 0 {
+				ignored[author] = reason
+			}
+			continue
+		}
+
 		r = append(r, reviewComment{
 			author:  author,
 			t:       ut,
@@ -95,7 +188,7 @@ func (rs reviewStats) preTreatComments(comments []sdk.PullRequestComments, start
 		return r[i].t.Before(r[j].t)
 	})
 
-	return r
+	return r, ignored
 }
 
 func (rs reviewStats) genCheckCmdFunc() func(cmd, author string) bool {
@@ -105,7 +198,7 @@ func (rs reviewStats) genCheckCmdFunc() func(cmd, author string) bool {
 		return canApplyCmd(
 			cmd,
 			prAuthor == author,
-			rs.pr.isApprover(author),
+			rs.isApprover(cmd, author),
 			rs.cfg.AllowSelfApprove,
 		)
 	}
@@ -116,7 +209,13 @@ func (rs reviewStats) numberOfReviewers() int {
 }
 
 func (rs reviewStats) isReviewer(author string) bool {
-	return rs.reviewers.Has(author)
+	return rs.reviewers.Has(author) || rs.ruleApprovers.Has(author)
+}
+
+// isApprover returns whether the author can apply the command as an
+// approver. The approvers of rules can only /approve.
+func (rs reviewStats) isApprover(cmd, author string) bool {
+	return rs.pr.isApprover(author) || (cmd == cmdAPPROVE && rs.ruleApprovers.Has(author))
 }
 
 func (rs reviewStats) genCheckCmdFuncReview() func(cmd, author string) bool {
@@ -126,7 +225,7 @@ func (rs reviewStats) genCheckCmdFuncReview() func(cmd, author string) bool {
 		return canApplyCmds(
 			cmd,
 			prAuthor == author,
-			rs.pr.isApprover(author),
+			rs.isApprover(cmd, author),
 			rs.pr.isReviewwer(author),
 			rs.cfg.AllowSelfApprove,
 		)
@@ -1,25 +1,28 @@
 package main
 
 import (
+	"strings"
+
 	"github.com/opensourceways/repo-owners-cache/repoowners"
 	"github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/util/sets"
 )
 
 type suggestingApprover struct {
-	pr    *pullRequest
-	cfg   reviewConfig
-	owner repoowners.RepoOwner
+	pr          *pullRequest
+	cfg         reviewConfig
+	owner       repoowners.RepoOwner
+	unavailable sets.String
 }
 
 func (p suggestingApprover) selectApprovers(as []string, n int) []string {
-	excluded := sets.NewString(as...)
+	excluded := sets.NewString(as...).Union(p.unavailable)
 
 	if !p.cfg.AllowSelfApprove {
 		excluded.Delete(p.pr.prAuthor())
 	}
 
-	return getReviewers(fakeReviewersClient{s: &p}, p.pr.files, n, excluded)
+	return getReviewers(fakeReviewersClient{s: &p}, p.pr.files, n, excluded, nil)
 }
 
 func (p suggestingApprover) filterApprover(assignees []string) []string {
@@ -80,6 +83,17 @@ func mergeSlices(s []string, s1 []string) []string {
 	return r
 }
 
+// joinNonEmpty joins the items which are not empty.
+func joinNonEmpty(sep string, items ...string) string {
+	r := make([]string, 0, len(items))
+	for _, item := range items {
+		if item != "" {
+			r = append(r, item)
+		}
+	}
+	return strings.Join(r, sep)
+}
+
 func difference(s, s1 []string) []string {
 	if len(s) == 0 || len(s1) == 0 {
 		return s
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/opensourceways/community-robot-lib/giteeclient"
+	"github.com/opensourceways/repo-owners-cache/repoowners"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const cmdCC = "CC"
+
+type teamConfig struct {
+	// Name is the name of team which can be used in OWNERS file
+	// and commands like `/cc @sig-kernel-maintainers`.
+	Name string `json:"name" required:"true"`
+
+	// Members is the gitee accounts of the team.
+	Members []string `json:"members" required:"true"`
+}
+
+func (t teamConfig) validate() error {
+	if t.Name == "" {
+		return fmt.Errorf("missing name of team")
+	}
+
+	if len(t.Members) == 0 {
+		return fmt.Errorf("missing members of team %s", t.Name)
+	}
+
+	return nil
+}
+
+type teams struct {
+	members  map[string]sets.String
+	memberOf map[string]sets.String
+}
+
+func newTeams(cfg []teamConfig) *teams {
+	t := &teams{
+		members:  make(map[string]sets.String, len(cfg)),
+		memberOf: map[string]sets.String{},
+	}
+
+	for i := range cfg {
+		name := normalizeLogin(cfg[i].Name)
+
+		members := sets.NewString()
+		for _, m := range cfg[i].Members {
+			members.Insert(normalizeLogin(m))
+		}
+		t.members[name] = members
+
+		for m := range members {
+			if v, ok := t.memberOf[m]; ok {
+				v.Insert(name)
+			} else {
+				t.memberOf[m] = sets.NewString(name)
+			}
+		}
+	}
+
+	return t
+}
+
+func (t *teams) isEmpty() bool {
+	return t == nil || len(t.members) == 0
+}
+
+func (t *teams) isTeam(name string) bool {
+	if t == nil {
+		return false
+	}
+
+	_, ok := t.members[name]
+	return ok
+}
+
+// expand replaces the team names in s with the members of them.
+func (t *teams) expand(s sets.String) sets.String {
+	if t.isEmpty() {
+		return s
+	}
+
+	r := sets.NewString()
+	for item := range s {
+		if v, ok := t.members[item]; ok {
+			r = r.Union(v)
+		} else {
+			r.Insert(item)
+		}
+	}
+
+	return r
+}
+
+func (t *teams) teamsOf(login string) []string {
+	if t == nil {
+		return nil
+	}
+
+	return t.memberOf[login].List()
+}
+
+// teamOwner expands the team names in OWNERS files to the members,
+// so that any member of a team can act as the team.
+type teamOwner struct {
+	repoowners.RepoOwner
+
+	teams *teams
+}
+
+func newTeamOwner(owner repoowners.RepoOwner, t *teams) repoowners.RepoOwner {
+	if t.isEmpty() {
+		return owner
+	}
+
+	return teamOwner{RepoOwner: owner, teams: t}
+}
+
+func (o teamOwner) Approvers(path string) sets.String {
+	return o.teams.expand(o.RepoOwner.Approvers(path))
+}
+
+func (o teamOwner) LeafApprovers(path string) sets.String {
+	return o.teams.expand(o.RepoOwner.LeafApprovers(path))
+}
+
+func (o teamOwner) Reviewers(path string) sets.String {
+	return o.teams.expand(o.RepoOwner.Reviewers(path))
+}
+
+func (o teamOwner) LeafReviewers(path string) sets.String {
+	return o.teams.expand(o.RepoOwner.LeafReviewers(path))
+}
+
+func (o teamOwner) AllReviewers() sets.String {
+	return o.teams.expand(o.RepoOwner.AllReviewers())
+}
+
+func (o teamOwner) TopLevelApprovers() sets.String {
+	return o.teams.expand(o.RepoOwner.TopLevelApprovers())
+}
+
+// handleCCComment mentions the members of teams which are cc'ed.
+func (bot *robot) handleCCComment(e *noteEventInfo, cfg *botConfig, log *logrus.Entry) error {
+	if cfg.teams.isEmpty() {
+		return nil
+	}
+
+	var lines []string
+	for _, name := range parseCCCommand(e.GetComment().GetBody()) {
+		if !cfg.teams.isTeam(name) {
+			continue
+		}
+
+		members := cfg.teams.members[name].List()
+		for i := range members {
+			members[i] = "@" + members[i]
+		}
+
+		lines = append(lines, fmt.Sprintf("*%s*: %s", name, strings.Join(members, " ")))
+	}
+
+	if len(lines) == 0 {
+		return nil
+	}
+
+	log.Infof("expand the teams of cc: %s", strings.Join(lines, "; "))
+
+	org, repo := e.GetOrgRepo()
+
+	return bot.client.CreatePRComment(
+		org, repo, e.GetPRNumber(),
+		giteeclient.GenResponseWithReference(
+			e.NoteEvent,
+			"Please take a look at this PR.\n"+strings.Join(lines, notificationLineSpliter),
+		),
+	)
+}
+
+func parseCCCommand(comment string) []string {
+	var r []string
+	for _, match := range commandRegex.FindAllStringSubmatch(comment, -1) {
+		if strings.ToUpper(match[1]) != cmdCC {
+			continue
+		}
+
+		for _, item := range strings.Fields(match[2]) {
+			r = append(r, normalizeLogin(item))
+		}
+	}
+
+	return r
+}